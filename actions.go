@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"gw/dispatcher/debugger/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// App actions run from command palette.
+type (
+	switchTabMsg      int
+	splitTabMsg       int
+	closePaneMsg      struct{}
+	rotatePanesMsg    struct{}
+	connectProfileMsg string
+	exportSnapshotMsg struct{}
+	toggleHelpMsg     struct{}
+	toggleErrorLogMsg struct{}
+	setThemeMsg       string
+	quitMsg           struct{}
+)
+
+// Write plain text of view to snapshots dir in config dir, return file path.
+func exportSnapshot(tabName string, view string) (string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, "snapshots")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	name := fmt.Sprintf("%s-%s.txt", strings.ReplaceAll(tabName, " ", "_"), time.Now().Format("20060102-150405"))
+	path := filepath.Join(dir, name)
+	return path, os.WriteFile(path, []byte(ansi.Strip(view)+"\n"), 0o644)
+}
@@ -2,13 +2,21 @@ package main
 
 import (
 	"fmt"
-	"gw/dispatcher/debugger/keylist"
+	"gw/dispatcher/debugger/audit"
+	"gw/dispatcher/debugger/config"
+	"gw/dispatcher/debugger/guard"
+	"gw/dispatcher/debugger/keymap"
 	"gw/dispatcher/debugger/msgs"
-	"gw/dispatcher/debugger/queue"
-	"gw/dispatcher/debugger/runnerwatcher"
+	"gw/dispatcher/debugger/notify"
+	"gw/dispatcher/debugger/palette"
 	"gw/dispatcher/debugger/style"
+	"gw/dispatcher/debugger/tab"
 	"gw/dispatcher/debugger/theme"
+	"sort"
+	"strings"
 
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/redis/go-redis/v9"
@@ -17,88 +25,200 @@ import (
 // Footer (status bar) height is fixed.
 const footerHeight = 2
 
+// Source of notifications from app itself.
+const notifySource = "app"
+
 // Defune app style.
 var (
-	itemStyle      = style.W().M.Align(lipgloss.Center)
-	selectModifier = lipgloss.NewStyle().
-			Background(theme.G().PanelLight).
-			Foreground(theme.G().TextDark)
-	unselectModifier = lipgloss.NewStyle().Background(theme.G().PanelDark)
-
-	headerBox  = lipgloss.NewStyle().Background(theme.G().PanelDark)
-	footerBox  = lipgloss.NewStyle().Height(footerHeight).Background(theme.G().PanelDark)
+	itemStyle  = style.W().M.Align(lipgloss.Center)
 	leftBorder = lipgloss.NewStyle().Border(lipgloss.NormalBorder(), false, true, false, false)
 	mainBox    = lipgloss.NewStyle()
+	helpBox    = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+	paneTitle  = lipgloss.NewStyle().Bold(true).Padding(0, 1).MaxHeight(paneTitleHeight)
+)
+
+// App colors, rebuilt on theme change.
+var (
+	selectModifier   lipgloss.Style
+	unselectModifier lipgloss.Style
+	headerBox        lipgloss.Style
+	footerBox        lipgloss.Style
+	stuckAlert       lipgloss.Style
+	readOnly         lipgloss.Style
 )
 
-// Interface for component which can update status bar message.
-type Statusbar interface {
-	StatusBarView() string
+func init() {
+	theme.OnChange(func(t theme.Theme) {
+		selectModifier = t.On(t.PanelLight)
+		unselectModifier = lipgloss.NewStyle().Background(t.PanelDark)
+		headerBox = lipgloss.NewStyle().Background(t.PanelDark)
+		footerBox = lipgloss.NewStyle().Height(footerHeight).Background(t.PanelDark)
+		stuckAlert = t.On(t.Error)
+		readOnly = t.On(t.Warning).Height(footerHeight).Padding(0, 1).Bold(true)
+	})
 }
 
 // Redis config use to store redis setup.
 type redisConfig struct {
+	profile  string
 	host     string
 	port     int
 	password string
 	db       int
+	readonly bool
 }
 
 func newRedisConfig() redisConfig {
 	return redisConfig{
+		profile:  "",
 		host:     "127.0.0.1",
 		port:     6379,
 		password: "",
 		db:       0,
+		readonly: false,
 	}
 }
 
-func connectRedis(cfg *redisConfig) tea.Cmd {
+func (c *redisConfig) addr() string {
+	return fmt.Sprintf("%s:%d@%d", c.host, c.port, c.db)
+}
+
+func connectRedis(cfg *redisConfig, auditLog *audit.Logger) tea.Cmd {
 	return func() tea.Msg {
 		rdb := redis.NewClient(&redis.Options{
 			Addr:     fmt.Sprintf("%s:%d", cfg.host, cfg.port),
 			Password: cfg.password,
 			DB:       cfg.db,
 		})
-		return msgs.RedisStateMsg{Client: rdb}
+		// First hook added is the outermost, audit goes first so it wraps
+		// read-only hook and also records rejected commands.
+		if auditLog != nil {
+			rdb.AddHook(audit.Hook{Logger: auditLog})
+		}
+		// Every command go through the hook, so nothing can write in read-only mode.
+		if cfg.readonly {
+			rdb.AddHook(guard.ReadOnlyHook{})
+		}
+		return msgs.RedisStateMsg{Client: rdb, ReadOnly: cfg.readonly}
+	}
+}
+
+// Keys handled by app itself.
+type appKeys struct {
+	Quit      key.Binding
+	ForceQuit key.Binding
+	NextTab   key.Binding
+	PrevTab   key.Binding
+	Help      key.Binding
+	Palette   key.Binding
+	ErrorLog  key.Binding
+	Theme     key.Binding
+	JumpTab   key.Binding
+
+	// Split mode, pane keys work only with more than one pane.
+	Split       key.Binding
+	ClosePane   key.Binding
+	FocusPane   key.Binding
+	GrowPane    key.Binding
+	ShrinkPane  key.Binding
+	RotatePanes key.Binding
+}
+
+func newAppKeys(tabs []tab.Tab) appKeys {
+	jump := make([]string, 0, len(tabs))
+	for _, t := range tabs {
+		if t.Key != "" {
+			jump = append(jump, t.Key)
+		}
+	}
+	jumpHelp := strings.Join(jump, "/")
+	if len(jump) > 2 {
+		jumpHelp = jump[0] + "-" + jump[len(jump)-1]
+	}
+	return appKeys{
+		Quit:      keymap.New("quit", "quit", "q", "esc"),
+		ForceQuit: keymap.New("force_quit", "quit anywhere", "ctrl+c"),
+		NextTab:   keymap.New("next_tab", "next tab", "tab"),
+		PrevTab:   keymap.New("prev_tab", "previous tab", "shift+tab"),
+		Help:      keymap.New("help", "toggle help", "?"),
+		Palette:   keymap.New("palette", "command palette", "ctrl+p"),
+		ErrorLog:  keymap.New("error_log", "toggle error log", "!"),
+		Theme:     keymap.New("theme", "next theme", "ctrl+y"),
+		// Keys come from tabs, shown as one entry.
+		JumpTab: key.NewBinding(key.WithKeys(jump...), key.WithHelp(jumpHelp, "jump to tab")),
+
+		Split:       keymap.New("split", "split with next tab", "|"),
+		ClosePane:   keymap.New("close_pane", "close pane", "ctrl+x"),
+		FocusPane:   keymap.New("focus_pane", "focus next pane", "ctrl+n"),
+		GrowPane:    keymap.New("grow_pane", "grow pane", "]"),
+		ShrinkPane:  keymap.New("shrink_pane", "shrink pane", "["),
+		RotatePanes: keymap.New("rotate_panes", "stack/unstack panes", "ctrl+t"),
 	}
 }
 
 type App struct {
-	tabs   []string
+	tabs   []tab.Tab
 	models []tea.Model
 	csr    int
+	// Panes in split mode, csr is always one of them.
+	layout layout
+
+	keys     appKeys
+	help     help.Model
+	showHelp bool
+	palette  palette.Model
+
+	// Toasts and error log, shown over main box.
+	notify       notify.Model
+	showErrorLog bool
+
+	// Result of last app action, shown until next key press.
+	notice string
+
+	// Profiles to switch to, profile picked in palette is kept for main to restart with.
+	profiles      map[string]config.Profile
+	switchProfile string
 
 	rdb       *redis.Client
 	rdbConfig redisConfig
+	auditLog  *audit.Logger
+
+	// Number of stuck entries found by last scan.
+	stuckCount int
 
 	width  int
 	height int
+	// Main box height models were last sized to, footer height changes with focused tab.
+	laidOut int
 }
 
-func NewApp() App {
+func NewApp(tabs []tab.Tab, auditLog *audit.Logger) App {
 	app := App{
-		tabs: []string{
-			"runner",
-			"raw keys",
-			"queue",
-		},
-		models: []tea.Model{
-			runnerwatcher.New(),
-			keylist.New(),
-			queue.New(),
-		},
-		csr: 0,
+		tabs:   tabs,
+		models: make([]tea.Model, len(tabs)),
+		csr:    0,
+
+		keys:    newAppKeys(tabs),
+		help:    help.New(),
+		palette: palette.New(),
+		notify:  notify.New(),
 
 		rdb:       nil,
 		rdbConfig: newRedisConfig(),
+		auditLog:  auditLog,
+	}
+	for i := range tabs {
+		app.models[i] = tabs[i].New()
 	}
 	return app
 }
 
 func (a App) Init() tea.Cmd {
 	var cmds []tea.Cmd
-	cmds = append(cmds, connectRedis(&a.rdbConfig))
+	cmds = append(cmds, connectRedis(&a.rdbConfig, a.auditLog))
+	if a.auditLog != nil {
+		cmds = append(cmds, a.auditLog.WaitErr())
+	}
 	for i := range a.models {
 		cmds = append(cmds, a.models[i].Init())
 	}
@@ -106,81 +226,463 @@ func (a App) Init() tea.Cmd {
 }
 
 func (a App) View() string {
-	// Build header.
-	header := make([]string, len(a.tabs))
-	for i := range a.tabs {
-		if i == a.csr {
-			header[i] = itemStyle.Inherit(selectModifier).Render(a.tabs[i])
-		} else {
-			header[i] = itemStyle.Inherit(unselectModifier).Render(a.tabs[i])
-		}
+	renderHeader := a.headerView()
+	renderFooter := a.footerView()
+
+	// Calc main box height.
+	mainBoxHeight := a.height - lipgloss.Height(renderHeader) - lipgloss.Height(renderFooter)
+
+	// Render main data, or help of focused tab over it.
+	main := ""
+	if a.layout.split() {
+		main = a.panesView(mainBoxHeight)
+	} else if a.models[a.csr] != nil {
+		main = a.models[a.csr].View()
+	}
+	if a.palette.Opened() {
+		main = lipgloss.Place(a.width, mainBoxHeight, lipgloss.Center, lipgloss.Top, a.palette.View(a.width))
+	}
+	if a.showErrorLog {
+		main = lipgloss.Place(a.width, mainBoxHeight, lipgloss.Center, lipgloss.Center,
+			helpBox.Render("Errors\n\n"+a.notify.LogView(a.errorLogSize())))
 	}
+	if a.showHelp {
+		main = lipgloss.Place(a.width, mainBoxHeight, lipgloss.Center, lipgloss.Center,
+			helpBox.Render(a.tabs[a.csr].Name+" keys\n\n"+a.help.FullHelpView(a.fullHelp())))
+	}
+	renderMain := mainBox.Height(mainBoxHeight).MaxHeight(mainBoxHeight).Render(main)
+	renderMain = a.notify.Overlay(renderMain, a.width, mainBoxHeight)
 
-	// Render header data, fill the rest of the line.
-	renderHeader := headerBox.Width(a.width).Render(
-		lipgloss.JoinHorizontal(lipgloss.Center, header...),
+	// Render app.
+	return lipgloss.JoinVertical(lipgloss.Left,
+		renderHeader, renderMain, renderFooter,
 	)
+}
+
+// Whether tab of name is enabled.
+func (a App) hasTab(name string) bool {
+	for _, t := range a.tabs {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
 
+// Status bar with short help of focused tab above it.
+func (a App) footerView() string {
 	// Build footer, fill the rest of line.
-	redisStatus := leftBorder.Render(lipgloss.JoinVertical(lipgloss.Center,
-		"Redis",
-		fmt.Sprintf("%s:%d@%d", a.rdbConfig.host, a.rdbConfig.port, a.rdbConfig.db),
-	))
+	redisTitle := "Redis"
+	if a.rdbConfig.profile != "" {
+		redisTitle = fmt.Sprintf("Redis(%s)", a.rdbConfig.profile)
+	}
+	redisStatus := lipgloss.JoinVertical(lipgloss.Center,
+		redisTitle,
+		a.rdbConfig.addr(),
+	)
+	if a.rdbConfig.readonly {
+		redisStatus = lipgloss.JoinHorizontal(lipgloss.Top, readOnly.Render("READ-ONLY"), redisStatus)
+	}
+	redisStatus = leftBorder.Render(redisStatus)
+	errorCounter := lipgloss.JoinVertical(lipgloss.Center, "Errors", fmt.Sprintf("%d", a.notify.Unread()))
+	if a.notify.Unread() != 0 {
+		errorCounter = stuckAlert.Render(errorCounter)
+	}
+	counters := leftBorder.Render(errorCounter)
+	// Nothing counts stuck entries without stuck tab.
+	if a.hasTab("stuck") {
+		stuckCounter := lipgloss.JoinVertical(lipgloss.Center, "Stuck", fmt.Sprintf("%d", a.stuckCount))
+		if a.stuckCount != 0 {
+			stuckCounter = stuckAlert.Render(stuckCounter)
+		}
+		counters = lipgloss.JoinHorizontal(lipgloss.Top, leftBorder.Render(stuckCounter), counters)
+	}
 	statusBar := ""
 	switch model := a.models[a.csr].(type) {
-	case Statusbar:
+	case tab.StatusBar:
 		statusBar = model.StatusBarView()
 	}
-	space := a.width - lipgloss.Width(redisStatus)
+	space := a.width - lipgloss.Width(redisStatus) - lipgloss.Width(counters)
 	renderFooter := footerBox.Width(a.width).Render(
 		lipgloss.JoinHorizontal(lipgloss.Top,
 			redisStatus,
+			counters,
 			lipgloss.PlaceHorizontal(space, lipgloss.Right, statusBar)),
 	)
 
-	// Calc main box height.
-	mainBoxHeight := a.height - lipgloss.Height(renderHeader) - lipgloss.Height(renderFooter)
+	// Short help of focused tab above footer.
+	a.help.Width = a.width
+	shortHelp := a.help.ShortHelpView(a.shortHelp())
+	if a.notice != "" {
+		shortHelp = a.notice
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, shortHelp, renderFooter)
+}
 
-	// Render main data.
-	main := ""
-	if a.models[a.csr] != nil {
-		main = a.models[a.csr].View()
+// Error log fits in help box over main box.
+func (a App) errorLogSize() (int, int) {
+	const chrome = 4
+	return max(1, a.width-chrome), max(1, a.mainHeight()-chrome-2)
+}
+
+// Main box height left by header and footer.
+func (a App) mainHeight() int {
+	return max(0, a.height-lipgloss.Height(a.headerView())-lipgloss.Height(a.footerView()))
+}
+
+// Panes side by side or stacked, each with tab name above its content.
+func (a App) panesView(height int) string {
+	views := make([]string, len(a.layout.panes))
+	for p, r := range a.layout.rects(a.width, height) {
+		i := a.layout.panes[p]
+		c := a.layout.content(p, r)
+
+		title := paneTitle.Inherit(unselectModifier)
+		if i == a.csr {
+			title = paneTitle.Inherit(selectModifier)
+		}
+		body := lipgloss.NewStyle().MaxWidth(c.width).MaxHeight(c.height).Render(a.models[i].View())
+		views[p] = lipgloss.JoinVertical(lipgloss.Left,
+			title.Width(c.width).Render(a.tabs[i].Name),
+			lipgloss.Place(c.width, c.height, lipgloss.Left, lipgloss.Top, body),
+		)
+		if !a.layout.stacked && p > 0 {
+			views[p] = leftBorder.Render(views[p])
+		}
 	}
-	renderMain := mainBox.Height(mainBoxHeight).MaxHeight(mainBoxHeight).Render(main)
+	if a.layout.stacked {
+		return lipgloss.JoinVertical(lipgloss.Left, views...)
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, views...)
+}
 
-	// Render app.
-	return lipgloss.JoinVertical(lipgloss.Left,
-		renderHeader, renderMain, renderFooter,
+// Send every model the size it's shown in, main box or pane in split mode.
+func (a App) resize() (App, tea.Cmd) {
+	a.laidOut = a.mainHeight()
+	sizes := make(map[int]tea.WindowSizeMsg)
+	if a.layout.split() {
+		for p, r := range a.layout.rects(a.width, a.laidOut) {
+			c := a.layout.content(p, r)
+			sizes[a.layout.panes[p]] = tea.WindowSizeMsg{Width: c.width, Height: c.height}
+		}
+	}
+
+	var cmds []tea.Cmd
+	for i := range a.models {
+		size, ok := sizes[i]
+		if !ok {
+			size = tea.WindowSizeMsg{Width: a.width, Height: a.laidOut}
+		}
+		var c tea.Cmd
+		a.models[i], c = a.models[i].Update(size)
+		cmds = append(cmds, c)
+	}
+	return a, tea.Batch(cmds...)
+}
+
+// Focus tab, in split mode it replaces tab of focused pane unless it's in a pane already.
+func (a App) focus(i int) (App, tea.Cmd) {
+	if !a.layout.split() || a.layout.pane(i) >= 0 {
+		a.csr = i
+		return a, nil
+	}
+	a.layout = a.layout.replace(a.csr, i)
+	a.csr = i
+	return a.resize()
+}
+
+// Add pane of tab next to focused pane and focus it.
+func (a App) split(i int) (App, tea.Cmd) {
+	if i == a.csr || a.layout.pane(i) >= 0 {
+		return a, nil
+	}
+	a.layout = a.layout.add(a.csr, i)
+	a.csr = i
+	return a.resize()
+}
+
+// Next tab not shown in a pane, -1 if every tab is.
+func (a App) nextUnshown() int {
+	for n := 1; n < len(a.tabs); n++ {
+		i := (a.csr + n) % len(a.tabs)
+		if i != a.csr && a.layout.pane(i) < 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// Close focused pane, focus goes to pane before it.
+func (a App) closePane() (App, tea.Cmd) {
+	p := a.layout.pane(a.csr)
+	a.layout = a.layout.remove(a.csr)
+	if a.layout.split() {
+		a.csr = a.layout.panes[max(0, p-1)]
+	}
+	return a.resize()
+}
+
+// Handle pane keys, ok is false if key is not one of them.
+func (a App) handlePaneKey(msg tea.KeyMsg) (App, tea.Cmd, bool) {
+	if key.Matches(msg, a.keys.Split) {
+		i := a.nextUnshown()
+		if i < 0 {
+			a.notice = "Every tab is shown already."
+			return a, nil, true
+		}
+		a, c := a.split(i)
+		return a, c, true
+	}
+	if !a.layout.split() {
+		return a, nil, false
+	}
+
+	var c tea.Cmd
+	switch {
+	case key.Matches(msg, a.keys.ClosePane):
+		a, c = a.closePane()
+	case key.Matches(msg, a.keys.FocusPane):
+		p := a.layout.pane(a.csr)
+		a.csr = a.layout.panes[(p+1)%len(a.layout.panes)]
+	case key.Matches(msg, a.keys.GrowPane):
+		a.layout = a.layout.grow(a.csr, 1)
+		a, c = a.resize()
+	case key.Matches(msg, a.keys.ShrinkPane):
+		a.layout = a.layout.grow(a.csr, -1)
+		a, c = a.resize()
+	case key.Matches(msg, a.keys.RotatePanes):
+		a.layout.stacked = !a.layout.stacked
+		a, c = a.resize()
+	default:
+		return a, nil, false
+	}
+	return a, c, true
+}
+
+// Tab titles in header.
+func (a App) headerItems() []string {
+	header := make([]string, len(a.tabs))
+	for i := range a.tabs {
+		title := a.tabs[i].Name
+		if a.tabs[i].Key != "" {
+			title = a.tabs[i].Key + " " + title
+		}
+		if i == a.csr {
+			header[i] = itemStyle.Inherit(selectModifier).Render(title)
+		} else {
+			header[i] = itemStyle.Inherit(unselectModifier).Render(title)
+		}
+	}
+	return header
+}
+
+func (a App) headerView() string {
+	// Render header data, fill the rest of the line.
+	return headerBox.Width(a.width).Render(
+		lipgloss.JoinHorizontal(lipgloss.Center, a.headerItems()...),
 	)
 }
 
+// Click on header switches tab, other mouse events go to focused tab
+// with Y relative to main box.
+func (a App) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	if a.showErrorLog {
+		a.notify, _ = a.notify.Update(msg)
+		return a, nil
+	}
+	if a.palette.Opened() || a.showHelp {
+		return a, nil
+	}
+
+	headerHeight := lipgloss.Height(a.headerView())
+	if msg.Y < headerHeight {
+		if msg.Action != tea.MouseActionPress || msg.Button != tea.MouseButtonLeft {
+			return a, nil
+		}
+		x := 0
+		for i, item := range a.headerItems() {
+			x += lipgloss.Width(item)
+			if msg.X < x {
+				return a.focus(i)
+			}
+		}
+		return a, nil
+	}
+
+	msg.Y -= headerHeight
+	if !a.layout.split() {
+		return a.SendToFocused(msg)
+	}
+
+	// Press focuses pane under pointer, which gets the event relative to its content.
+	for p, r := range a.layout.rects(a.width, a.mainHeight()) {
+		if !r.contains(msg.X, msg.Y) {
+			continue
+		}
+		i := a.layout.panes[p]
+		if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+			a.csr = i
+		}
+		c := a.layout.content(p, r)
+		if !c.contains(msg.X, msg.Y) {
+			return a, nil
+		}
+		msg.X -= c.x
+		msg.Y -= c.y
+		var cmd tea.Cmd
+		a.models[i], cmd = a.models[i].Update(msg)
+		return a, cmd
+	}
+	return a, nil
+}
+
+// Update app, models are sized again when header or footer height changed.
 func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	m, c := a.update(msg)
+	a = m.(App)
+	if a.width == 0 || a.mainHeight() == a.laidOut {
+		return a, c
+	}
+	a, r := a.resize()
+	return a, tea.Batch(c, r)
+}
+
+func (a App) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 
 	case tea.WindowSizeMsg:
 		a.height = msg.Height
 		a.width = msg.Width
-		return a.Broadcast(msg)
+		return a.resize()
 
 	case msgs.RedisStateMsg:
 		a.rdb = msg.Client
 		return a.Broadcast(msg)
 
+	case msgs.StuckUpdateMsg:
+		if msg.Err == nil {
+			a.stuckCount = len(msg.Entries)
+		}
+		return a.Broadcast(msg)
+
 	case tea.QuitMsg:
 		if a.rdb != nil {
 			a.rdb.Close()
 		}
 
+	case switchTabMsg:
+		return a.focus(int(msg))
+
+	case splitTabMsg:
+		return a.split(int(msg))
+
+	case closePaneMsg:
+		return a.closePane()
+
+	case rotatePanesMsg:
+		a.layout.stacked = !a.layout.stacked
+		return a.resize()
+
+	case toggleHelpMsg:
+		a.showHelp = !a.showHelp
+
+	case toggleErrorLogMsg:
+		return a.toggleErrorLog(), nil
+
+	case setThemeMsg:
+		if err := theme.Set(string(msg)); err != nil {
+			return a, notify.Err(notifySource, err)
+		}
+		a.notice = "Theme " + string(msg)
+
+	case notify.Msg:
+		var c tea.Cmd
+		a.notify, c = a.notify.Update(msg)
+		if a.showErrorLog {
+			a.notify = a.notify.Read()
+		}
+		return a, c
+
+	case notify.ExpireMsg:
+		a.notify, _ = a.notify.Update(msg)
+
+	case audit.ErrMsg:
+		return a, tea.Batch(notify.Err("audit", msg.Err), a.auditLog.WaitErr())
+
+	case quitMsg:
+		return a, tea.Quit
+
+	case connectProfileMsg:
+		a.switchProfile = string(msg)
+		return a, tea.Quit
+
+	case exportSnapshotMsg:
+		path, err := exportSnapshot(a.tabs[a.csr].Name, a.models[a.csr].View())
+		if err != nil {
+			return a, notify.Err(notifySource, fmt.Errorf("export snapshot: %w", err))
+		}
+		a.notice = "Snapshot saved to " + path
+
+	case tea.MouseMsg:
+		return a.handleMouse(msg)
+
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "esc", "q":
+		if key.Matches(msg, a.keys.ForceQuit) {
 			return a, tea.Quit
-		case "tab":
-			a.csr = (a.csr + 1) % len(a.tabs)
-		case "shift+tab":
-			a.csr--
-			if a.csr < 0 {
-				a.csr = len(a.tabs) - 1
+		}
+		a.notice = ""
+		if a.palette.Opened() {
+			var chosen *palette.Item
+			var c tea.Cmd
+			a.palette, chosen, c = a.palette.Update(msg)
+			if chosen == nil {
+				return a, c
+			}
+			return a.runAction(chosen)
+		}
+		if a.showErrorLog {
+			if key.Matches(msg, a.keys.ErrorLog, a.keys.Quit) {
+				return a.toggleErrorLog(), nil
+			}
+			a.notify, _ = a.notify.Update(msg)
+			return a, nil
+		}
+		// Help overlay takes keys until closed.
+		if a.showHelp {
+			if key.Matches(msg, a.keys.Help, a.keys.Quit) {
+				a.showHelp = false
+			}
+			return a, nil
+		}
+		if c, ok := a.models[a.csr].(tab.InputCapturer); ok && c.CapturingInput() {
+			return a.SendToFocused(msg)
+		}
+		if a, c, ok := a.handlePaneKey(msg); ok {
+			return a, c
+		}
+
+		switch {
+		case key.Matches(msg, a.keys.Quit):
+			return a, tea.Quit
+		case key.Matches(msg, a.keys.Help):
+			a.showHelp = true
+		case key.Matches(msg, a.keys.ErrorLog):
+			return a.toggleErrorLog(), nil
+		case key.Matches(msg, a.keys.Theme):
+			return a.update(setThemeMsg(theme.Next()))
+		case key.Matches(msg, a.keys.Palette):
+			var c tea.Cmd
+			a.palette, c = a.palette.Open(a.actions())
+			return a, c
+		case key.Matches(msg, a.keys.NextTab):
+			return a.focus((a.csr + 1) % len(a.tabs))
+		case key.Matches(msg, a.keys.PrevTab):
+			return a.focus((a.csr + len(a.tabs) - 1) % len(a.tabs))
+		case key.Matches(msg, a.keys.JumpTab):
+			for i := range a.tabs {
+				if a.tabs[i].Key == msg.String() {
+					return a.focus(i)
+				}
 			}
 		default:
 			return a.SendToFocused(msg)
@@ -193,6 +695,93 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return a, nil
 }
 
+// Show error log at newest error and mark errors read, or hide it.
+func (a App) toggleErrorLog() App {
+	a.showErrorLog = !a.showErrorLog
+	if a.showErrorLog {
+		_, height := a.errorLogSize()
+		a.notify = a.notify.Read().ScrollEnd(height)
+	}
+	return a
+}
+
+// Short help of focused tab followed by app keys.
+func (a App) shortHelp() []key.Binding {
+	result := make([]key.Binding, 0)
+	if h, ok := a.models[a.csr].(tab.Helper); ok {
+		result = append(result, h.Help().ShortHelp()...)
+	}
+	return append(result, a.keys.Help, a.keys.Palette, a.keys.ErrorLog, a.keys.Quit)
+}
+
+// Full help of focused tab, app keys in the last column.
+func (a App) fullHelp() [][]key.Binding {
+	result := make([][]key.Binding, 0)
+	if h, ok := a.models[a.csr].(tab.Helper); ok {
+		result = append(result, h.Help().FullHelp()...)
+	}
+	return append(result, []key.Binding{
+		a.keys.NextTab, a.keys.PrevTab, a.keys.JumpTab, a.keys.Palette, a.keys.ErrorLog, a.keys.Theme, a.keys.Help, a.keys.Quit, a.keys.ForceQuit,
+	}, []key.Binding{
+		a.keys.Split, a.keys.ClosePane, a.keys.FocusPane, a.keys.GrowPane, a.keys.ShrinkPane, a.keys.RotatePanes,
+	})
+}
+
+// Actions of app and every tab for command palette.
+func (a App) actions() []palette.Item {
+	result := make([]palette.Item, 0)
+	for i := range a.tabs {
+		result = append(result, palette.Item{Title: "Switch to tab " + a.tabs[i].Name, Tab: -1, Msg: switchTabMsg(i)})
+	}
+	names := make([]string, 0, len(a.profiles))
+	for name := range a.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		result = append(result, palette.Item{Title: "Connect to profile " + name, Tab: -1, Msg: connectProfileMsg(name)})
+	}
+	for i := range a.tabs {
+		if i != a.csr && a.layout.pane(i) < 0 {
+			result = append(result, palette.Item{Title: "Split with tab " + a.tabs[i].Name, Tab: -1, Msg: splitTabMsg(i)})
+		}
+	}
+	if a.layout.split() {
+		result = append(result,
+			palette.Item{Title: "Close pane", Tab: -1, Msg: closePaneMsg{}},
+			palette.Item{Title: "Stack/unstack panes", Tab: -1, Msg: rotatePanesMsg{}},
+		)
+	}
+	for _, name := range theme.Names() {
+		result = append(result, palette.Item{Title: "Use theme " + name, Tab: -1, Msg: setThemeMsg(name)})
+	}
+	result = append(result,
+		palette.Item{Title: "Export snapshot of current tab", Tab: -1, Msg: exportSnapshotMsg{}},
+		palette.Item{Title: "Toggle help", Tab: -1, Msg: toggleHelpMsg{}},
+		palette.Item{Title: "Toggle error log", Tab: -1, Msg: toggleErrorLogMsg{}},
+		palette.Item{Title: "Quit", Tab: -1, Msg: quitMsg{}},
+	)
+
+	for i := range a.models {
+		if actioner, ok := a.models[i].(tab.Actioner); ok {
+			for _, action := range actioner.Actions() {
+				result = append(result, palette.Item{Source: a.tabs[i].Name, Title: action.Title, Tab: i, Msg: action.Msg})
+			}
+		}
+	}
+	return result
+}
+
+// Run action picked in palette, tab action focuses its tab first.
+func (a App) runAction(item *palette.Item) (tea.Model, tea.Cmd) {
+	if item.Tab < 0 {
+		return a.update(item.Msg)
+	}
+	a, c := a.focus(item.Tab)
+	m, cmd := a.SendToFocused(item.Msg)
+	return m, tea.Batch(c, cmd)
+}
+
 func (a App) Broadcast(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	for i := range a.models {
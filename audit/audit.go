@@ -0,0 +1,177 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"gw/dispatcher/debugger/guard"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/redis/go-redis/v9"
+)
+
+// Name of audit file in config dir.
+const FileName = "audit.jsonl"
+
+// One mutating command issued by the debugger.
+type Record struct {
+	Session string    `json:"session"`
+	Time    time.Time `json:"time"`
+	Profile string    `json:"profile,omitempty"`
+	Addr    string    `json:"addr"`
+	User    string    `json:"user"`
+	Command string    `json:"command"`
+	Args    []string  `json:"args"`
+	Result  string    `json:"result"`
+	Err     string    `json:"error,omitempty"`
+}
+
+// Logger append records to a JSONL file, safe for concurrent use.
+type Logger struct {
+	path    string
+	session string
+	profile string
+	addr    string
+	user    string
+
+	mu sync.Mutex
+
+	// Failed writes of hook, waiting to be shown to user.
+	errs chan error
+}
+
+// Use to deliver a failed audit write, see WaitErr.
+type ErrMsg struct {
+	Err error
+}
+
+// Create logger for one run of the debugger, addr looks like "host:port@db".
+func NewLogger(path, profile, addr string) *Logger {
+	return &Logger{
+		path:    path,
+		session: fmt.Sprintf("%s-%d", time.Now().Format("20060102150405"), os.Getpid()),
+		profile: profile,
+		addr:    addr,
+		user:    currentUser(),
+		errs:    make(chan error, 1),
+	}
+}
+
+func (l *Logger) Path() string {
+	return l.path
+}
+
+// Id of current run, use to tell records of this session from previous ones.
+func (l *Logger) Session() string {
+	return l.session
+}
+
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// Append record of a finished command.
+func (l *Logger) Log(cmd redis.Cmder) error {
+	r := Record{
+		Session: l.session,
+		Time:    time.Now(),
+		Profile: l.profile,
+		Addr:    l.addr,
+		User:    l.user,
+		Command: cmd.Name(),
+	}
+	for _, arg := range cmd.Args()[1:] {
+		r.Args = append(r.Args, fmt.Sprint(arg))
+	}
+	if err := cmd.Err(); err != nil && err != redis.Nil {
+		r.Result = "error"
+		r.Err = err.Error()
+	} else {
+		// Cmd string looks like "<args...>: <reply>", only keep the reply.
+		prefix := strings.Join(append([]string{fmt.Sprint(cmd.Args()[0])}, r.Args...), " ") + ": "
+		r.Result = strings.TrimPrefix(cmd.String(), prefix)
+	}
+
+	data, err := json.Marshal(&r)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// Keep error for WaitErr, one is enough while the last isn't taken yet.
+func (l *Logger) report(err error) {
+	select {
+	case l.errs <- fmt.Errorf("write audit log %s: %w", l.path, err):
+	default:
+	}
+}
+
+// Command wait for the next failed write of hook, run it again after each ErrMsg.
+func (l *Logger) WaitErr() tea.Cmd {
+	return func() tea.Msg {
+		return ErrMsg{Err: <-l.errs}
+	}
+}
+
+// Hook log every mutating command after it's done, including those rejected.
+type Hook struct {
+	Logger *Logger
+}
+
+func (h Hook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (h Hook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		err := next(ctx, cmd)
+		if guard.IsWrite(cmd.Args()) {
+			if logErr := h.Logger.Log(cmd); logErr != nil {
+				h.Logger.report(logErr)
+			}
+		}
+		return err
+	}
+}
+
+func (h Hook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		err := next(ctx, cmds)
+		for _, cmd := range cmds {
+			if !guard.IsWrite(cmd.Args()) {
+				continue
+			}
+			if logErr := h.Logger.Log(cmd); logErr != nil {
+				h.Logger.report(logErr)
+			}
+		}
+		return err
+	}
+}
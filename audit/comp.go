@@ -0,0 +1,309 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gw/dispatcher/debugger/keymap"
+	"gw/dispatcher/debugger/listview"
+	"gw/dispatcher/debugger/notify"
+	"gw/dispatcher/debugger/style"
+	"gw/dispatcher/debugger/tab"
+	"gw/dispatcher/debugger/theme"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Define audit table column width and align.
+var (
+	timeStyle    = style.W().L
+	userStyle    = style.W().M
+	addrStyle    = style.W().L
+	resultStyle  = style.W().M
+	commandStyle = lipgloss.NewStyle()
+	statusStyle  = style.W().M.Padding(0, 1)
+)
+
+// Rebuilt on theme change.
+var (
+	textInverse        lipgloss.Style
+	textInverseAndBold lipgloss.Style
+	errorColor         lipgloss.Style
+	selectedRow        lipgloss.Style
+)
+
+func init() {
+	theme.OnChange(func(t theme.Theme) {
+		textInverse = t.On(t.BackgroundInverse)
+		textInverseAndBold = textInverse.Bold(true)
+		errorColor = t.Fg(t.Error)
+		selectedRow = t.On(t.PanelLight)
+	})
+}
+
+// Reload audit file period in second.
+const reloadPeriod = 2
+
+// Source of notifications from this tab.
+const notifySource = "audit"
+
+// Format to print record time.
+const timePrintFormat = "2006-01-02 15:04:05"
+
+// Table header above records, position indicator below.
+const headerHeight, footerHeight = 1, 1
+
+// Use to deliver records appended to audit file since last load.
+type recordsLoadedMsg struct {
+	Records []Record
+	// Where next load starts.
+	Offset int64
+	// File is new or was truncated, records loaded before are gone.
+	Reset bool
+	Err   error
+}
+
+type keyMap struct {
+	All key.Binding
+}
+
+func newKeyMap() keyMap {
+	return keyMap{All: keymap.New("audit.all", "toggle all sessions", "a")}
+}
+
+type Model struct {
+	bindings keyMap
+	logger   *Logger
+
+	records []Record
+	// Bytes of audit file loaded, only lines after it are read on reload.
+	offset int64
+	err    error
+
+	// Show records of all sessions or only current one.
+	all bool
+
+	list listview.Model
+}
+
+func New(logger *Logger) Model {
+	return Model{bindings: newKeyMap(), logger: logger, list: listview.New()}
+}
+
+func (m Model) Init() tea.Cmd {
+	return loadRecords(m.logger.Path(), 0)
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case recordsLoadedMsg:
+		// Tell error once, not on every reload.
+		failed := msg.Err != nil && m.err == nil
+		m.err = msg.Err
+		if msg.Err == nil {
+			if msg.Reset {
+				m.records = nil
+			}
+			m.records = append(m.records, msg.Records...)
+			m.offset = msg.Offset
+			m.list = m.list.SetCount(len(m.visible()))
+		}
+		next := delayRunCommand(reloadPeriod, loadRecords(m.logger.Path(), m.offset))
+		if failed {
+			return m, tea.Batch(next, notify.Err(notifySource, fmt.Errorf("load audit log: %w", msg.Err)))
+		}
+		return m, next
+
+	case tea.WindowSizeMsg:
+		m.list = m.list.SetHeight(msg.Height - headerHeight - footerHeight)
+		return m, nil
+
+	case tea.MouseMsg:
+		if list, ok := m.list.Update(msg); ok {
+			m.list = list
+			return m, nil
+		}
+		if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+			m.list, _ = m.list.Click(msg.Y - headerHeight)
+		}
+		return m, nil
+
+	case toggleAllMsg:
+		return m.toggleAll(), nil
+
+	case tea.KeyMsg:
+		if key.Matches(msg, m.bindings.All) {
+			return m.toggleAll(), nil
+		}
+		m.list, _ = m.list.Update(msg)
+		return m, nil
+	}
+
+	return m, nil
+}
+
+// Records to show, newest first.
+func (m Model) visible() []Record {
+	result := make([]Record, 0, len(m.records))
+	for i := len(m.records) - 1; i >= 0; i-- {
+		if m.all || m.records[i].Session == m.logger.Session() {
+			result = append(result, m.records[i])
+		}
+	}
+	return result
+}
+
+func (m Model) View() string {
+	var builder strings.Builder
+	builder.WriteString(tableHeader() + "\n")
+
+	if m.err != nil {
+		builder.WriteString(m.err.Error())
+		return builder.String()
+	}
+
+	records := m.visible()
+	if len(records) == 0 {
+		if m.all {
+			builder.WriteString("No mutating command recorded yet.")
+		} else {
+			builder.WriteString("No mutating command in this session, " + keymap.HelpText(m.bindings.All) + ".")
+		}
+		return builder.String()
+	}
+
+	start, end := m.list.Range()
+	for i := start; i < end; i++ {
+		row := recordRow(&records[i])
+		if i == m.list.Cursor() {
+			row = selectedRow.Render(row)
+		}
+		builder.WriteString(row + "\n")
+	}
+	builder.WriteString(m.list.Indicator())
+	return builder.String()
+}
+
+// Use to toggle all sessions from palette.
+type toggleAllMsg struct{}
+
+// Show records of all sessions or back to current one.
+func (m Model) toggleAll() Model {
+	m.all = !m.all
+	m.list = m.list.SetCount(len(m.visible())).SetCursor(0)
+	return m
+}
+
+func (m Model) Actions() []tab.Action {
+	return []tab.Action{{Title: "Toggle all sessions", Msg: toggleAllMsg{}}}
+}
+
+func (m Model) Help() help.KeyMap {
+	return keymap.Help{
+		Short: []key.Binding{m.bindings.All},
+		Full:  [][]key.Binding{m.list.Bindings(), {m.bindings.All}},
+	}
+}
+
+func (m Model) StatusBarView() string {
+	scope := "session"
+	if m.all {
+		scope = "all"
+	}
+	return statusStyle.Render(fmt.Sprintf("%d %s", len(m.visible()), scope))
+}
+
+func tableHeader() string {
+	var builder strings.Builder
+	builder.WriteString(timeStyle.Inherit(textInverseAndBold).Render("TIME"))
+	builder.WriteString(userStyle.Inherit(textInverseAndBold).Render("USER"))
+	builder.WriteString(addrStyle.Inherit(textInverseAndBold).Render("REDIS"))
+	builder.WriteString(resultStyle.Inherit(textInverseAndBold).Render("RESULT"))
+	builder.WriteString(commandStyle.Inherit(textInverseAndBold).Render("COMMAND"))
+	return builder.String()
+}
+
+func recordRow(r *Record) string {
+	addr := r.Addr
+	if r.Profile != "" {
+		addr = fmt.Sprintf("%s(%s)", r.Profile, r.Addr)
+	}
+
+	result := resultStyle.Render(r.Result)
+	command := strings.Join(append([]string{r.Command}, r.Args...), " ")
+	if r.Err != "" {
+		result = resultStyle.Inherit(errorColor).Render("error")
+		command = fmt.Sprintf("%s (%s)", command, r.Err)
+	}
+
+	var builder strings.Builder
+	builder.WriteString(timeStyle.Render(r.Time.Local().Format(timePrintFormat)))
+	builder.WriteString(userStyle.Render(r.User))
+	builder.WriteString(addrStyle.Render(addr))
+	builder.WriteString(result)
+	builder.WriteString(commandStyle.Render(command))
+	return builder.String()
+}
+
+// Run command after a delay, unit is seconds.
+func delayRunCommand(sec time.Duration, cmd tea.Cmd) tea.Cmd {
+	return func() tea.Msg {
+		time.Sleep(sec * time.Second)
+		return cmd()
+	}
+}
+
+// Command read records appended to audit file after offset, a missing file has
+// no record. File shorter than offset was truncated, it's read from start.
+func loadRecords(path string, offset int64) tea.Cmd {
+	return func() tea.Msg {
+		f, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return recordsLoadedMsg{Reset: true}
+		}
+		if err != nil {
+			return recordsLoadedMsg{Err: err}
+		}
+		defer f.Close()
+
+		info, err := f.Stat()
+		if err != nil {
+			return recordsLoadedMsg{Err: err}
+		}
+		result := recordsLoadedMsg{Offset: offset}
+		if info.Size() < offset {
+			result.Offset, result.Reset = 0, true
+		}
+		if _, err := f.Seek(result.Offset, io.SeekStart); err != nil {
+			return recordsLoadedMsg{Err: err}
+		}
+
+		reader := bufio.NewReader(f)
+		for {
+			line, err := reader.ReadBytes('\n')
+			// Line without newline is still being written, read it next time.
+			if err == io.EOF {
+				return result
+			}
+			if err != nil {
+				return recordsLoadedMsg{Err: err}
+			}
+			result.Offset += int64(len(line))
+
+			var r Record
+			// Skip broken line.
+			if err := json.Unmarshal(line, &r); err != nil {
+				continue
+			}
+			result.Records = append(result.Records, r)
+		}
+	}
+}
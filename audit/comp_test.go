@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadRecordsReadsAppendedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	write := func(flag int, text string) {
+		f, err := os.OpenFile(path, flag|os.O_WRONLY|os.O_CREATE, 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if _, err := f.WriteString(text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	load := func(offset int64) recordsLoadedMsg {
+		msg := loadRecords(path, offset)().(recordsLoadedMsg)
+		if msg.Err != nil {
+			t.Fatal(msg.Err)
+		}
+		return msg
+	}
+
+	if msg := load(0); !msg.Reset || len(msg.Records) != 0 {
+		t.Fatalf("missing file: got %+v", msg)
+	}
+
+	// Partial last line is left for next load.
+	write(os.O_APPEND, `{"command":"set"}`+"\n"+`broken`+"\n"+`{"command":"del"`)
+	first := load(0)
+	if len(first.Records) != 1 || first.Records[0].Command != "set" {
+		t.Fatalf("first load: got %+v", first.Records)
+	}
+
+	write(os.O_APPEND, "}\n")
+	second := load(first.Offset)
+	if second.Reset || len(second.Records) != 1 || second.Records[0].Command != "del" {
+		t.Fatalf("second load: got reset %v records %+v", second.Reset, second.Records)
+	}
+
+	write(os.O_TRUNC, `{"command":"get"}`+"\n")
+	third := load(second.Offset)
+	if !third.Reset || len(third.Records) != 1 || third.Records[0].Command != "get" {
+		t.Fatalf("after truncate: got reset %v records %+v", third.Reset, third.Records)
+	}
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Directory name under user config dir.
+const appDirName = "gw-debugger"
+
+// Name of config file in config dir.
+const fileName = "config.yaml"
+
+// One redis server the debugger can connect to.
+type Profile struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
+
+	// Production profile is read-only unless ReadOnly says otherwise.
+	Production bool  `yaml:"production"`
+	ReadOnly   *bool `yaml:"readonly"`
+}
+
+// Whether profile should run in read-only mode.
+func (p *Profile) IsReadOnly() bool {
+	if p.ReadOnly != nil {
+		return *p.ReadOnly
+	}
+	return p.Production
+}
+
+type Config struct {
+	Profiles map[string]Profile `yaml:"profiles"`
+
+	// Runner name to "host" or "host:port", use to find runner connection without client name.
+	RunnerAddrs map[string]string `yaml:"runner_addrs"`
+
+	// Tabs to show in order, every tab when empty.
+	Tabs []string `yaml:"tabs"`
+
+	// Tabs to hide.
+	DisabledTabs []string `yaml:"disabled_tabs"`
+
+	// Tabs shown together in split panes at start, like "[runner, queue]".
+	Split []string `yaml:"split"`
+
+	// Stack split panes top to bottom instead of side by side.
+	SplitStacked bool `yaml:"split_stacked"`
+
+	// Binding name to keys, replace default keys, like "up: [up, k]".
+	Keys map[string][]string `yaml:"keys"`
+
+	// Theme name, built-in one or file name in themes dir without ".yaml".
+	Theme string `yaml:"theme"`
+
+	// Color mode, auto, truecolor, 256, 16 or none for monochrome.
+	Color string `yaml:"color"`
+}
+
+// Directory holding config file and other data of the debugger.
+func Dir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, appDirName), nil
+}
+
+// Default config file path.
+func DefaultPath() string {
+	dir, err := Dir()
+	if err != nil {
+		return fileName
+	}
+	return filepath.Join(dir, fileName)
+}
+
+// Load config from path, a missing file gives an empty config.
+func Load(path string) (Config, error) {
+	cfg := Config{Profiles: make(map[string]Profile)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = make(map[string]Profile)
+	}
+	return cfg, nil
+}
+
+// Find profile by name.
+func (c *Config) Profile(name string) (Profile, error) {
+	p, ok := c.Profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("profile %q not found", name)
+	}
+	return p, nil
+}
@@ -0,0 +1,546 @@
+package console
+
+import (
+	"context"
+	"fmt"
+	"gw/dispatcher/debugger/guard"
+	"gw/dispatcher/debugger/keymap"
+	"gw/dispatcher/debugger/msgs"
+	"gw/dispatcher/debugger/notify"
+	"gw/dispatcher/debugger/style"
+	"gw/dispatcher/debugger/theme"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	hintStyle   = lipgloss.NewStyle().Faint(true)
+	statusStyle = style.W().L.Padding(0, 1)
+)
+
+// Rebuilt on theme change.
+var (
+	promptStyle lipgloss.Style
+	errorStyle  lipgloss.Style
+)
+
+func init() {
+	theme.OnChange(func(t theme.Theme) {
+		promptStyle = t.Fg(t.PanelLight).Bold(true)
+		errorStyle = t.Fg(t.Error)
+	})
+}
+
+// Source of notifications from this tab.
+const notifySource = "console"
+
+const (
+	// Replies kept in scrollback.
+	maxOutput = 500
+
+	// Input and hint lines below output.
+	chromeHeight = 2
+)
+
+// Use to deliver reply of a command.
+type replyMsg struct {
+	Line  string
+	Reply interface{}
+	Err   error
+}
+
+// Use to deliver command names and whether they write.
+type commandsMsg struct {
+	Writes map[string]bool
+	Err    error
+}
+
+// One command and its reply in scrollback.
+type output struct {
+	Line  string
+	Reply string
+	Err   bool
+}
+
+type Model struct {
+	bindings keyMap
+	rdb      *redis.Client
+	readonly bool
+
+	input textinput.Model
+	// Command names known by server, value tells whether it writes.
+	commands map[string]bool
+	keys     []string
+
+	historyPath string
+	history     []string
+	// Position when browsing history by up/down, len(history) means new line.
+	historyPos int
+	// Reverse search of history.
+	searching bool
+	search    string
+	found     int
+
+	outputs []output
+	scroll  int
+	hint    string
+
+	height int
+	width  int
+}
+
+type keyMap struct {
+	keymap.Common
+
+	Type     key.Binding
+	Run      key.Binding
+	Complete key.Binding
+	Search   key.Binding
+	Older    key.Binding
+	Newer    key.Binding
+	PageUp   key.Binding
+	PageDown key.Binding
+
+	// Reverse search of history.
+	UseMatch   key.Binding
+	StopSearch key.Binding
+}
+
+func newKeyMap() keyMap {
+	return keyMap{
+		Common:     keymap.NewCommon(),
+		Type:       keymap.New("console.type", "type command", "enter", "i"),
+		Run:        keymap.New("console.run", "run", "enter"),
+		Complete:   keymap.New("console.complete", "complete", "tab"),
+		Search:     keymap.New("console.search", "search history", "ctrl+r"),
+		Older:      keymap.New("console.older", "older command", "up"),
+		Newer:      keymap.New("console.newer", "newer command", "down"),
+		PageUp:     keymap.New("console.page_up", "scroll up", "pgup"),
+		PageDown:   keymap.New("console.page_down", "scroll down", "pgdown"),
+		UseMatch:   keymap.New("console.use_match", "use match", "enter"),
+		StopSearch: keymap.New("console.stop_search", "stop search", "esc", "ctrl+g"),
+	}
+}
+
+// History of each profile is kept in its own file at historyPath.
+func New(historyPath string) Model {
+	bindings := newKeyMap()
+	input := textinput.New()
+	input.Prompt = "> "
+	input.Placeholder = "redis command, " + keymap.HelpText(bindings.Complete, bindings.Search, bindings.Back)
+
+	return Model{bindings: bindings, input: input, historyPath: historyPath, commands: make(map[string]bool)}
+}
+
+func (m Model) Init() tea.Cmd {
+	return loadHistory(m.historyPath)
+}
+
+// Command read COMMAND for names and flags.
+func listCommands(rdb *redis.Client) tea.Cmd {
+	return func() tea.Msg {
+		infos, err := rdb.Command(context.Background()).Result()
+		if err != nil {
+			return commandsMsg{Err: err}
+		}
+		result := make(map[string]bool, len(infos))
+		for name, info := range infos {
+			writes := false
+			for _, f := range info.Flags {
+				if f == "write" {
+					writes = true
+				}
+			}
+			result[strings.ToLower(name)] = writes
+		}
+		return commandsMsg{Writes: result}
+	}
+}
+
+// Command send args as is.
+func runCommand(rdb *redis.Client, line string, args []string) tea.Cmd {
+	return func() tea.Msg {
+		a := make([]interface{}, len(args))
+		for i := range args {
+			a[i] = args[i]
+		}
+		reply, err := rdb.Do(context.Background(), a...).Result()
+		if err == redis.Nil {
+			err = nil
+		}
+		return replyMsg{Line: line, Reply: reply, Err: err}
+	}
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case msgs.RedisStateMsg:
+		m.rdb = msg.Client
+		m.readonly = msg.ReadOnly
+		if m.rdb == nil {
+			return m, nil
+		}
+		return m, listCommands(m.rdb)
+
+	case msgs.KeysListedMsg:
+		m.keys = msg.Keys
+		return m, nil
+
+	case commandsMsg:
+		if msg.Err != nil {
+			return m, notify.Err(notifySource, fmt.Errorf("load command table: %w", msg.Err))
+		}
+		m.commands = msg.Writes
+		return m, nil
+
+	case historyLoadedMsg:
+		m.history = msg.Lines
+		m.historyPos = len(m.history)
+		if msg.Err != nil {
+			return m, notify.Err(notifySource, fmt.Errorf("load history: %w", msg.Err))
+		}
+		return m, nil
+
+	case replyMsg:
+		out := output{Line: msg.Line, Reply: formatReply(msg.Reply, "")}
+		if msg.Err != nil {
+			out.Reply = "(error) " + msg.Err.Error()
+			out.Err = true
+		}
+		return m.addOutput(out), nil
+
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+		m.width = msg.Width
+		return m.scrollBy(0), nil
+
+	case tea.KeyMsg:
+		if m.input.Focused() {
+			return m.handleInputKey(msg)
+		}
+		switch {
+		case key.Matches(msg, m.bindings.Type):
+			return m, m.input.Focus()
+		case key.Matches(msg, m.bindings.PageUp):
+			return m.scrollBy(1), nil
+		case key.Matches(msg, m.bindings.PageDown):
+			return m.scrollBy(-1), nil
+		}
+		return m, nil
+	}
+
+	var c tea.Cmd
+	m.input, c = m.input.Update(msg)
+	return m, c
+}
+
+func (m Model) addOutput(out output) Model {
+	m.outputs = append(m.outputs, out)
+	if len(m.outputs) > maxOutput {
+		m.outputs = m.outputs[len(m.outputs)-maxOutput:]
+	}
+	m.scroll = 0
+	return m
+}
+
+// Scroll output up by pages, down if negative, never past first or last line.
+func (m Model) scrollBy(pages int) Model {
+	size := m.outputSize()
+	m.scroll += pages * max(1, size-1)
+	m.scroll = max(0, min(m.scroll, len(m.outputLines())-size))
+	return m
+}
+
+// Rows output is shown in.
+func (m Model) outputSize() int {
+	return max(1, m.height-chromeHeight)
+}
+
+// Commands and replies, one screen line each.
+func (m Model) outputLines() []string {
+	row := lipgloss.NewStyle().MaxWidth(m.width)
+	lines := make([]string, 0)
+	for _, out := range m.outputs {
+		lines = append(lines, promptStyle.Render("> "+out.Line))
+		reply := out.Reply
+		if out.Err {
+			reply = errorStyle.Render(reply)
+		}
+		for _, line := range strings.Split(reply, "\n") {
+			lines = append(lines, row.Render(line))
+		}
+	}
+	return lines
+}
+
+func (m Model) handleInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.searching {
+		return m.handleSearchKey(msg)
+	}
+	m.hint = ""
+
+	switch {
+	case key.Matches(msg, m.bindings.Back):
+		m.input.Blur()
+		return m, nil
+	case key.Matches(msg, m.bindings.Run):
+		return m.submit()
+	case key.Matches(msg, m.bindings.Complete):
+		return m.complete(), nil
+	case key.Matches(msg, m.bindings.Search):
+		m.searching = true
+		m.search = ""
+		m.found = -1
+		return m, nil
+	case key.Matches(msg, m.bindings.Older):
+		if m.historyPos > 0 {
+			m.historyPos--
+			m.input.SetValue(m.history[m.historyPos])
+			m.input.CursorEnd()
+		}
+		return m, nil
+	case key.Matches(msg, m.bindings.Newer):
+		if m.historyPos < len(m.history)-1 {
+			m.historyPos++
+			m.input.SetValue(m.history[m.historyPos])
+			m.input.CursorEnd()
+		} else {
+			m.historyPos = len(m.history)
+			m.input.SetValue("")
+		}
+		return m, nil
+	case key.Matches(msg, m.bindings.PageUp):
+		return m.scrollBy(1), nil
+	case key.Matches(msg, m.bindings.PageDown):
+		return m.scrollBy(-1), nil
+	}
+
+	var c tea.Cmd
+	m.input, c = m.input.Update(msg)
+	return m, c
+}
+
+// Reverse incremental search, ctrl+r again finds an older match.
+func (m Model) handleSearchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.bindings.StopSearch):
+		m.searching = false
+		return m, nil
+	case key.Matches(msg, m.bindings.UseMatch):
+		m.searching = false
+		if m.found >= 0 {
+			m.input.SetValue(m.history[m.found])
+			m.input.CursorEnd()
+		}
+		return m, nil
+	case key.Matches(msg, m.bindings.Search):
+		before := len(m.history)
+		if m.found >= 0 {
+			before = m.found
+		}
+		if i := searchHistory(m.history, m.search, before); i >= 0 {
+			m.found = i
+		}
+		return m, nil
+	}
+
+	// Other keys edit search text.
+	switch msg.Type {
+	case tea.KeyBackspace:
+		if m.search != "" {
+			m.search = m.search[:len(m.search)-1]
+		}
+	case tea.KeySpace:
+		m.search += " "
+	case tea.KeyRunes:
+		m.search += string(msg.Runes)
+	default:
+		return m, nil
+	}
+	m.found = searchHistory(m.history, m.search, len(m.history))
+	return m, nil
+}
+
+func (m Model) submit() (tea.Model, tea.Cmd) {
+	line := strings.TrimSpace(m.input.Value())
+	if line == "" || m.rdb == nil {
+		return m, nil
+	}
+	m.input.SetValue("")
+
+	var saved tea.Cmd
+	if len(m.history) == 0 || m.history[len(m.history)-1] != line {
+		m.history = append(m.history, line)
+		if err := appendHistory(m.historyPath, line); err != nil {
+			saved = notify.Err(notifySource, fmt.Errorf("save history: %w", err))
+		}
+	}
+	m.historyPos = len(m.history)
+
+	next, c := m.run(line)
+	return next, tea.Batch(saved, c)
+}
+
+// Run command line, commands rejected before sent are answered in output.
+func (m Model) run(line string) (tea.Model, tea.Cmd) {
+	args, err := splitArgs(line)
+	if err != nil {
+		return m.addOutput(output{Line: line, Reply: "(error) " + err.Error(), Err: true}), nil
+	}
+	if len(args) == 0 {
+		return m, nil
+	}
+
+	name := strings.ToLower(args[0])
+	if m.readonly && (m.commands[name] || guard.IsWrite(toInterfaces(args))) {
+		return m.addOutput(output{
+			Line:  line,
+			Reply: fmt.Sprintf("(error) %s: %s writes data, rejected in read-only mode", guard.ErrReadOnly, name),
+			Err:   true,
+		}), nil
+	}
+	switch name {
+	case "monitor", "subscribe", "psubscribe", "ssubscribe":
+		return m.addOutput(output{Line: line, Reply: "(error) use monitor or pubsub tab for " + name, Err: true}), nil
+	}
+
+	return m, runCommand(m.rdb, line, args)
+}
+
+func toInterfaces(args []string) []interface{} {
+	result := make([]interface{}, len(args))
+	for i := range args {
+		result[i] = args[i]
+	}
+	return result
+}
+
+// Complete last word, command name for the first word, otherwise key.
+func (m Model) complete() Model {
+	value := m.input.Value()
+	start := strings.LastIndexAny(value, " ") + 1
+	word := value[start:]
+
+	candidates := make([]string, 0)
+	if start == 0 || strings.TrimSpace(value[:start]) == "" {
+		lower := strings.ToLower(word)
+		for name := range m.commands {
+			if strings.HasPrefix(name, lower) {
+				candidates = append(candidates, name)
+			}
+		}
+	} else {
+		for _, key := range m.keys {
+			if strings.HasPrefix(key, word) {
+				candidates = append(candidates, key)
+			}
+		}
+	}
+	sort.Strings(candidates)
+
+	switch len(candidates) {
+	case 0:
+		m.hint = "no completion"
+		if start != 0 && len(m.keys) == 0 {
+			m.hint = "no completion, keys come from search in raw keys tab"
+		}
+		return m
+	case 1:
+		m.input.SetValue(value[:start] + candidates[0] + " ")
+	default:
+		m.input.SetValue(value[:start] + commonPrefix(candidates))
+		shown := candidates
+		if len(shown) > 20 {
+			shown = shown[:20]
+		}
+		m.hint = strings.Join(shown, "  ")
+		if len(candidates) > len(shown) {
+			m.hint += fmt.Sprintf("  ... %d more", len(candidates)-len(shown))
+		}
+	}
+	m.input.CursorEnd()
+	return m
+}
+
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, w := range words[1:] {
+		for !strings.HasPrefix(w, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// Typing a command needs every key, tab completes.
+func (m Model) CapturingInput() bool {
+	return m.input.Focused()
+}
+
+func (m Model) View() string {
+	if m.rdb == nil {
+		return "Redis disconnected."
+	}
+
+	lines := m.outputLines()
+	size := m.outputSize()
+	end := max(0, len(lines)-m.scroll)
+	start := max(0, end-size)
+
+	var builder strings.Builder
+	for _, line := range lines[start:end] {
+		builder.WriteString(line + "\n")
+	}
+	for i := end - start; i < size; i++ {
+		builder.WriteString("\n")
+	}
+
+	if m.searching {
+		match := ""
+		if m.found >= 0 {
+			match = m.history[m.found]
+		}
+		builder.WriteString(fmt.Sprintf("(reverse-i-search)`%s': %s\n", m.search, match))
+	} else {
+		builder.WriteString(m.input.View() + "\n")
+	}
+
+	switch {
+	case m.hint != "":
+		builder.WriteString(hintStyle.Render(m.hint))
+	case !m.input.Focused():
+		builder.WriteString(hintStyle.Render(keymap.HelpText(m.bindings.Type, m.bindings.PageUp, m.bindings.PageDown)))
+	}
+	return builder.String()
+}
+
+func (m Model) Help() help.KeyMap {
+	k := m.bindings
+	switch {
+	case m.searching:
+		return keymap.Help{
+			Short: []key.Binding{k.UseMatch, k.Search, k.StopSearch},
+			Full:  [][]key.Binding{{k.UseMatch, k.Search, k.StopSearch}},
+		}
+	case m.input.Focused():
+		return keymap.Help{
+			Short: []key.Binding{k.Run, k.Complete, k.Search, k.Back},
+			Full:  [][]key.Binding{{k.Run, k.Complete, k.Search, k.Back}, {k.Older, k.Newer, k.PageUp, k.PageDown}},
+		}
+	}
+	return keymap.Help{
+		Short: []key.Binding{k.Type, k.PageUp, k.PageDown},
+		Full:  [][]key.Binding{{k.Type, k.PageUp, k.PageDown}},
+	}
+}
+
+func (m Model) StatusBarView() string {
+	return statusStyle.Render(fmt.Sprintf("%d history", len(m.history)))
+}
@@ -0,0 +1,115 @@
+package console
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Lines kept in history file.
+const maxHistory = 1000
+
+type historyLoadedMsg struct {
+	Lines []string
+	Err   error
+}
+
+// Command read history file, a missing file is empty history.
+func loadHistory(path string) tea.Cmd {
+	return func() tea.Msg {
+		f, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return historyLoadedMsg{}
+		}
+		if err != nil {
+			return historyLoadedMsg{Err: err}
+		}
+		defer f.Close()
+
+		lines, err := readHistory(f)
+		if len(lines) > maxHistory {
+			lines = lines[len(lines)-maxHistory:]
+		}
+		return historyLoadedMsg{Lines: lines, Err: err}
+	}
+}
+
+// Non-empty lines of history file.
+func readHistory(f *os.File) ([]string, error) {
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// Append one line to history file, file is cut to last maxHistory lines once
+// it grows past that.
+func appendHistory(path, line string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(line + "\n"); err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	// Cheap check first, a line is one byte at least.
+	if info.Size() <= maxHistory {
+		return nil
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	lines, err := readHistory(f)
+	if err != nil || len(lines) <= maxHistory {
+		return err
+	}
+	return rewriteHistory(path, lines[len(lines)-maxHistory:])
+}
+
+// Replace history file with lines, a temp file is renamed over it so a crash
+// never leaves it half written.
+func rewriteHistory(path string, lines []string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(strings.Join(lines, "\n") + "\n"); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Latest history line before index containing text, -1 if none.
+func searchHistory(lines []string, text string, before int) int {
+	for i := min(before, len(lines)) - 1; i >= 0; i-- {
+		if strings.Contains(lines[i], text) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,30 @@
+package console
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAppendHistoryTrimsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	for i := 0; i < maxHistory+5; i++ {
+		if err := appendHistory(path, fmt.Sprintf("get key%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	lines, err := readHistory(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != maxHistory || lines[0] != "get key5" || lines[len(lines)-1] != fmt.Sprintf("get key%d", maxHistory+4) {
+		t.Errorf("got %d lines from %q to %q", len(lines), lines[0], lines[len(lines)-1])
+	}
+}
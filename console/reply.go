@@ -0,0 +1,107 @@
+package console
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Split command line into args, single and double quotes group words,
+// double quoted arg supports backslash escape.
+func splitArgs(line string) ([]string, error) {
+	result := make([]string, 0)
+	var current strings.Builder
+	inArg := false
+	quote := rune(0)
+	escaped := false
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			switch r {
+			case 'n':
+				current.WriteRune('\n')
+			case 't':
+				current.WriteRune('\t')
+			default:
+				current.WriteRune(r)
+			}
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				result = append(result, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, errors.New("unbalanced quotes")
+	}
+	if inArg {
+		result = append(result, current.String())
+	}
+	return result, nil
+}
+
+// Render reply like redis-cli does.
+func formatReply(v interface{}, indent string) string {
+	switch v := v.(type) {
+	case nil:
+		return "(nil)"
+	case error:
+		return "(error) " + v.Error()
+	case string:
+		return fmt.Sprintf("%q", v)
+	case int64:
+		return fmt.Sprintf("(integer) %d", v)
+	case float64:
+		return fmt.Sprintf("(double) %v", v)
+	case bool:
+		return fmt.Sprintf("(boolean) %v", v)
+	case []interface{}:
+		if len(v) == 0 {
+			return "(empty array)"
+		}
+		lines := make([]string, len(v))
+		width := len(fmt.Sprint(len(v)))
+		for i, item := range v {
+			prefix := fmt.Sprintf("%*d) ", width, i+1)
+			lines[i] = prefix + formatReply(item, indent+strings.Repeat(" ", len(prefix)))
+		}
+		return strings.Join(lines, "\n"+indent)
+	case map[interface{}]interface{}:
+		if len(v) == 0 {
+			return "(empty map)"
+		}
+		keys := make([]string, 0, len(v))
+		values := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			key := fmt.Sprint(k)
+			keys = append(keys, key)
+			values[key] = item
+		}
+		sort.Strings(keys)
+		lines := make([]string, len(keys))
+		for i, k := range keys {
+			prefix := fmt.Sprintf("%q => ", k)
+			lines[i] = prefix + formatReply(values[k], indent+strings.Repeat(" ", len(prefix)))
+		}
+		return strings.Join(lines, "\n"+indent)
+	}
+	return fmt.Sprint(v)
+}
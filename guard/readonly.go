@@ -0,0 +1,117 @@
+package guard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Returned for every mutating command issued in read-only mode.
+var ErrReadOnly = errors.New("read-only mode")
+
+// Commands which change data or server state.
+var writeCommands = map[string]bool{
+	"append": true, "bitfield": true, "bitop": true, "blmove": true, "blmpop": true,
+	"blpop": true, "brpop": true, "brpoplpush": true, "bzmpop": true, "bzpopmax": true,
+	"bzpopmin": true, "copy": true, "decr": true, "decrby": true, "del": true,
+	"expire": true, "expireat": true, "flushall": true, "flushdb": true, "geoadd": true,
+	"georadius": true, "georadiusbymember": true, "geosearchstore": true, "getdel": true,
+	"getex": true, "getset": true, "hdel": true, "hexpire": true, "hincrby": true,
+	"hincrbyfloat": true, "hmset": true, "hpersist": true, "hset": true, "hsetnx": true,
+	"incr": true, "incrby": true, "incrbyfloat": true, "linsert": true, "lmove": true,
+	"lmpop": true, "lpop": true, "lpush": true, "lpushx": true, "lrem": true,
+	"lset": true, "ltrim": true, "migrate": true, "move": true, "mset": true,
+	"msetnx": true, "persist": true, "pexpire": true, "pexpireat": true, "pfadd": true,
+	"pfmerge": true, "psetex": true, "publish": true, "rename": true, "renamenx": true,
+	"restore": true, "rpop": true, "rpoplpush": true, "rpush": true, "rpushx": true,
+	"sadd": true, "sdiffstore": true, "set": true, "setbit": true, "setex": true,
+	"setnx": true, "setrange": true, "sinterstore": true, "smove": true,
+	"spop": true, "srem": true, "sunionstore": true, "swapdb": true, "unlink": true,
+	"xack": true, "xadd": true, "xautoclaim": true, "xclaim": true, "xdel": true,
+	"xgroup": true, "xreadgroup": true, "xsetid": true, "xtrim": true, "zadd": true,
+	"zdiffstore": true, "zincrby": true, "zinterstore": true, "zmpop": true,
+	"zpopmax": true, "zpopmin": true, "zrangestore": true, "zrem": true,
+	"zremrangebylex": true, "zremrangebyrank": true, "zremrangebyscore": true,
+	"zunionstore": true,
+
+	// Scripts and admin commands may write anything.
+	"eval": true, "evalsha": true, "fcall": true, "function": true, "script": true,
+	"shutdown": true, "debug": true, "replicaof": true, "slaveof": true,
+	"bgsave": true, "save": true, "bgrewriteaof": true, "failover": true,
+}
+
+// Admin commands which only write with some sub commands.
+var writeSubCommands = map[string]map[string]bool{
+	"config":  {"set": true, "resetstat": true, "rewrite": true},
+	"client":  {"kill": true, "pause": true, "unpause": true, "unblock": true, "no-evict": true, "no-touch": true},
+	"acl":     {"setuser": true, "deluser": true, "load": true, "save": true},
+	"slowlog": {"reset": true},
+	"latency": {"reset": true},
+	"memory":  {"purge": true},
+	"module":  {"load": true, "loadex": true, "unload": true},
+}
+
+// Whether a command, given as its args, mutates redis.
+func IsWrite(args []interface{}) bool {
+	if len(args) == 0 {
+		return false
+	}
+	name := strings.ToLower(fmt.Sprint(args[0]))
+	if writeCommands[name] {
+		return true
+	}
+	// SORT only writes with STORE, a pattern named "store" is taken as write too.
+	if name == "sort" {
+		for _, arg := range args[1:] {
+			if strings.EqualFold(fmt.Sprint(arg), "store") {
+				return true
+			}
+		}
+		return false
+	}
+
+	subs, ok := writeSubCommands[name]
+	if !ok || len(args) < 2 {
+		return false
+	}
+	return subs[strings.ToLower(fmt.Sprint(args[1]))]
+}
+
+// Hook rejects every mutating command, install it on the client to make it read-only.
+type ReadOnlyHook struct{}
+
+func (ReadOnlyHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (ReadOnlyHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if IsWrite(cmd.Args()) {
+			err := fmt.Errorf("%w: %s is not allowed", ErrReadOnly, cmd.Name())
+			cmd.SetErr(err)
+			return err
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (ReadOnlyHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			if IsWrite(cmd.Args()) {
+				err := fmt.Errorf("%w: %s is not allowed", ErrReadOnly, cmd.Name())
+				for _, c := range cmds {
+					c.SetErr(err)
+				}
+				return err
+			}
+		}
+		return next(ctx, cmds)
+	}
+}
@@ -0,0 +1,368 @@
+package keylist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gw/dispatcher/debugger/keymap"
+	"gw/dispatcher/debugger/style"
+	"gw/dispatcher/debugger/theme"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	dialogTitle   = lipgloss.NewStyle().Bold(true)
+	previewKey    = style.W().XL
+	previewType   = style.W().S
+	previewTTL    = style.W().M
+	previewHeader = lipgloss.NewStyle().Bold(true)
+)
+
+// Rebuilt on theme change.
+var confirmText lipgloss.Style
+
+func init() {
+	theme.OnChange(func(t theme.Theme) {
+		confirmText = t.On(t.Warning).Padding(0, 1)
+	})
+}
+
+// Mutating action on keys.
+type action int
+
+const (
+	actionDel action = iota
+	actionUnlink
+	actionExpire
+	actionPersist
+	actionRename
+)
+
+func (a action) String() string {
+	switch a {
+	case actionDel:
+		return "DEL"
+	case actionUnlink:
+		return "UNLINK"
+	case actionExpire:
+		return "EXPIRE"
+	case actionPersist:
+		return "PERSIST"
+	case actionRename:
+		return "RENAME"
+	}
+	return "UNKNOWN"
+}
+
+type dialogStage int
+
+const (
+	dialogClosed dialogStage = iota
+	// Asking ttl or new name.
+	dialogInput
+	// Loading dry-run preview and waiting for confirmation.
+	dialogPreview
+	dialogRunning
+)
+
+// State of one key before action, use to preview what will change.
+type keyPreview struct {
+	Key    string
+	Exists bool
+	Type   string
+	TTL    time.Duration
+}
+
+type previewMsg struct {
+	Preview []keyPreview
+
+	// New name of RENAME is taken already.
+	DestExists bool
+
+	Err error
+}
+
+type actionDoneMsg struct {
+	Action  action
+	Results []string
+	Err     error
+}
+
+// Dialog to collect argument of an action, preview and confirm it.
+type dialog struct {
+	bindings keyMap
+	stage    dialogStage
+	action   action
+	keys     []string
+	input    textinput.Model
+
+	// Ttl in second of EXPIRE, or new name of RENAME.
+	arg string
+
+	preview    []keyPreview
+	destExists bool
+	err        error
+}
+
+func newDialog(a action, keys []string, rdb *redis.Client, bindings keyMap) (dialog, tea.Cmd) {
+	d := dialog{bindings: bindings, action: a, keys: keys, input: textinput.New()}
+
+	switch a {
+	case actionExpire:
+		d.stage = dialogInput
+		d.input.Placeholder = "ttl in seconds"
+		return d, d.input.Focus()
+	case actionRename:
+		d.stage = dialogInput
+		d.input.SetValue(keys[0])
+		return d, d.input.Focus()
+	}
+
+	d.stage = dialogPreview
+	return d, previewKeys(rdb, keys, "")
+}
+
+// New name of RENAME, empty for other actions.
+func (d *dialog) dest() string {
+	if d.action == actionRename {
+		return d.arg
+	}
+	return ""
+}
+
+// The command going to run on key, RENAMENX so an existing key is never overwritten.
+func (d *dialog) command(key string) string {
+	switch d.action {
+	case actionExpire:
+		return fmt.Sprintf("%s %s %s", d.action, key, d.arg)
+	case actionRename:
+		return fmt.Sprintf("RENAMENX %s %s", key, d.arg)
+	}
+	return fmt.Sprintf("%s %s", d.action, key)
+}
+
+// Describe what the action does to key.
+func (d *dialog) effect(p *keyPreview) string {
+	if !p.Exists {
+		return "skip, key not exists"
+	}
+	switch d.action {
+	case actionDel, actionUnlink:
+		return "removed"
+	case actionExpire:
+		return fmt.Sprintf("expire in %ss", d.arg)
+	case actionPersist:
+		return "no ttl"
+	case actionRename:
+		if d.destExists {
+			return "skip, " + d.arg + " exists"
+		}
+		return "renamed to " + d.arg
+	}
+	return ""
+}
+
+func (d dialog) Update(msg tea.Msg, rdb *redis.Client) (dialog, tea.Cmd) {
+	switch msg := msg.(type) {
+	case previewMsg:
+		d.preview = msg.Preview
+		d.destExists = msg.DestExists
+		d.err = msg.Err
+		return d, nil
+
+	case tea.KeyMsg:
+		switch d.stage {
+		case dialogInput:
+			switch {
+			case key.Matches(msg, d.bindings.Back):
+				d.stage = dialogClosed
+				return d, nil
+			case key.Matches(msg, d.bindings.Submit):
+				arg, err := d.validate(strings.TrimSpace(d.input.Value()))
+				if err != nil {
+					d.err = err
+					return d, nil
+				}
+				d.arg, d.err = arg, nil
+				d.stage = dialogPreview
+				d.input.Blur()
+				return d, previewKeys(rdb, d.keys, d.dest())
+			}
+			var c tea.Cmd
+			d.input, c = d.input.Update(msg)
+			return d, c
+
+		case dialogPreview:
+			switch {
+			case key.Matches(msg, d.bindings.Confirm):
+				if d.preview == nil {
+					return d, nil
+				}
+				d.stage = dialogRunning
+				return d, runAction(rdb, d.action, d.keys, d.arg)
+			case key.Matches(msg, d.bindings.Cancel):
+				d.stage = dialogClosed
+			}
+			return d, nil
+		}
+	}
+
+	return d, nil
+}
+
+func (d *dialog) validate(arg string) (string, error) {
+	switch d.action {
+	case actionExpire:
+		ttl, err := strconv.Atoi(arg)
+		if err != nil || ttl <= 0 {
+			return "", errors.New("ttl must be a positive number of seconds")
+		}
+	case actionRename:
+		if arg == "" {
+			return "", errors.New("new name is empty")
+		}
+		if arg == d.keys[0] {
+			return "", errors.New("new name is same as old one")
+		}
+	}
+	return arg, nil
+}
+
+func (d dialog) View() string {
+	var builder strings.Builder
+	builder.WriteString(dialogTitle.Render(fmt.Sprintf("%s %d key(s)", d.action, len(d.keys))) + "\n\n")
+
+	switch d.stage {
+	case dialogInput:
+		for _, key := range d.keys {
+			builder.WriteString(key + "\n")
+		}
+		builder.WriteString("\n" + d.input.View() + "\n")
+		if d.err != nil {
+			builder.WriteString(d.err.Error() + "\n")
+		}
+		builder.WriteString(keymap.HelpText(d.bindings.Submit, d.bindings.Back))
+		return builder.String()
+
+	case dialogRunning:
+		builder.WriteString("Running...")
+		return builder.String()
+	}
+
+	if d.err != nil {
+		builder.WriteString(d.err.Error() + "\n\n" + keymap.HelpText(d.bindings.Cancel))
+		return builder.String()
+	}
+	if d.preview == nil {
+		builder.WriteString("Loading preview...")
+		return builder.String()
+	}
+
+	// Dry-run preview, nothing written yet.
+	builder.WriteString(previewHeader.Render(
+		previewKey.Render("KEY")+previewType.Render("TYPE")+previewTTL.Render("TTL")+"AFTER") + "\n")
+	for i := range d.preview {
+		p := &d.preview[i]
+		ttl := "-"
+		if p.TTL > 0 {
+			ttl = p.TTL.String()
+		}
+		builder.WriteString(previewKey.Render(p.Key) + previewType.Render(p.Type) + previewTTL.Render(ttl) + d.effect(p) + "\n")
+	}
+
+	if d.destExists {
+		builder.WriteString("\n" + d.arg + " exists already, RENAMENX won't overwrite it and the key keeps its name.\n")
+	}
+	builder.WriteString("\nCommands:\n")
+	for _, key := range d.keys {
+		builder.WriteString("  " + d.command(key) + "\n")
+	}
+	builder.WriteString("\n" + confirmText.Render("Run above commands? "+keymap.HelpText(d.bindings.Confirm, d.bindings.Cancel)))
+	return builder.String()
+}
+
+// Command read type and ttl of keys without changing anything, dest is
+// new name of RENAME checked for existence, empty for other actions.
+func previewKeys(rdb *redis.Client, keys []string, dest string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		result := make([]keyPreview, len(keys))
+		for i, key := range keys {
+			t, err := rdb.Type(ctx, key).Result()
+			if err != nil {
+				return previewMsg{Err: err}
+			}
+			ttl, err := rdb.TTL(ctx, key).Result()
+			if err != nil {
+				return previewMsg{Err: err}
+			}
+			result[i] = keyPreview{Key: key, Exists: t != "none", Type: t, TTL: ttl}
+		}
+
+		msg := previewMsg{Preview: result}
+		if dest != "" {
+			n, err := rdb.Exists(ctx, dest).Result()
+			if err != nil {
+				return previewMsg{Err: err}
+			}
+			msg.DestExists = n > 0
+		}
+		return msg
+	}
+}
+
+// Command run action on every key, stop at first error.
+func runAction(rdb *redis.Client, a action, keys []string, arg string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		result := actionDoneMsg{Action: a}
+
+		for _, key := range keys {
+			var text string
+			var err error
+
+			switch a {
+			case actionDel:
+				var n int64
+				n, err = rdb.Del(ctx, key).Result()
+				text = fmt.Sprintf("%s: %d removed", key, n)
+			case actionUnlink:
+				var n int64
+				n, err = rdb.Unlink(ctx, key).Result()
+				text = fmt.Sprintf("%s: %d unlinked", key, n)
+			case actionExpire:
+				var ok bool
+				ttl, _ := strconv.Atoi(arg)
+				ok, err = rdb.Expire(ctx, key, time.Duration(ttl)*time.Second).Result()
+				text = fmt.Sprintf("%s: expire set %v", key, ok)
+			case actionPersist:
+				var ok bool
+				ok, err = rdb.Persist(ctx, key).Result()
+				text = fmt.Sprintf("%s: ttl removed %v", key, ok)
+			case actionRename:
+				// Destination may be created after preview, RENAMENX still won't overwrite it.
+				var ok bool
+				ok, err = rdb.RenameNX(ctx, key, arg).Result()
+				if err == nil && !ok {
+					err = fmt.Errorf("%s exists, not renamed", arg)
+				}
+				text = fmt.Sprintf("%s: renamed to %s", key, arg)
+			}
+
+			if err != nil {
+				result.Err = fmt.Errorf("%s: %w", key, err)
+				return result
+			}
+			result.Results = append(result.Results, text)
+		}
+		return result
+	}
+}
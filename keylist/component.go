@@ -3,43 +3,94 @@ package keylist
 import (
 	"context"
 	"fmt"
+	"gw/dispatcher/debugger/listview"
 	"gw/dispatcher/debugger/msgs"
+	"gw/dispatcher/debugger/notify"
+	"gw/dispatcher/debugger/requeue"
 	"gw/dispatcher/debugger/style"
+	"gw/dispatcher/debugger/theme"
+	"slices"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/redis/go-redis/v9"
 )
 
 const textInputHeght = 1
 
+// Source of notifications from this tab.
+const notifySource = "keys"
+
+// Line use to show result of last action.
+const noticeHeight = 1
+
 var statusbarStyle = style.W().M.Padding(0, 1)
 
-func New() Model {
+// Rebuilt on theme change.
+var (
+	cursorStyle lipgloss.Style
+	markedStyle lipgloss.Style
+)
+
+func init() {
+	theme.OnChange(func(t theme.Theme) {
+		cursorStyle = t.On(t.PanelLight)
+		markedStyle = t.Fg(t.Warning).Bold(true)
+	})
+}
+
+func New(separator string) Model {
 	ipt := textinput.New()
-	ipt.Focus()
+	ipt.Placeholder = "press / to search keys"
 
 	m := Model{
-		rdb:   nil,
-		keys:  nil,
-		err:   nil,
-		input: ipt,
+		bindings: newKeyMap(),
+		rdb:      nil,
+		keys:     nil,
+		err:      nil,
+		input:    ipt,
+		list:     listview.New(),
+		marked:   make(map[string]bool),
+		tree:     newTree(separator),
 	}
 	return m
 }
 
 type Model struct {
+	bindings  keyMap
 	rdb       *redis.Client
+	readonly  bool
 	keys      []string
 	err       error
 	pageSize  int
-	csr       int
+	width     int
+	list      listview.Model
 	input     textinput.Model
 	lastValue string
+
+	// Keys selected for next action.
+	marked map[string]bool
+
+	// Show keys as a tree grouped by separator.
+	treeMode bool
+	tree     tree
+
+	dialog dialog
+	value  valueView
+	notice string
 }
 
 func (m Model) View() string {
+	if m.dialog.stage != dialogClosed {
+		return m.dialog.View()
+	}
+	if m.value.stage != valueClosed {
+		return m.value.View()
+	}
+
 	var builder strings.Builder
 	builder.WriteString(m.input.View() + "\n")
 
@@ -56,19 +107,45 @@ func (m Model) View() string {
 		return builder.String()
 	}
 
-	pos, size := m.csr, m.pageSize
-
-	for pos < len(m.keys) && size > 0 {
-		builder.WriteString(m.keys[pos] + "\n")
-		pos++
+	list := m.list
+	if m.treeMode {
+		builder.WriteString(m.tree.view(m.marked))
+		list = m.tree.list
+	} else {
+		start, end := m.list.Range()
+		for pos := start; pos < end; pos++ {
+			line := "  " + m.keys[pos]
+			if m.marked[m.keys[pos]] {
+				line = markedStyle.Render("* " + m.keys[pos])
+			}
+			if pos == m.list.Cursor() && !m.input.Focused() {
+				line = cursorStyle.Render(line)
+			}
+			builder.WriteString(line + "\n")
+		}
 	}
+
+	// Notice on the left, scroll position on the right, below the last row.
+	start, end := list.Range()
+	builder.WriteString(strings.Repeat("\n", max(0, m.pageSize-noticeHeight-(end-start))))
+	indicator := list.Indicator()
+	space := max(1, m.width-lipgloss.Width(m.notice)-lipgloss.Width(indicator))
+	builder.WriteString(m.notice + strings.Repeat(" ", space) + indicator)
 	return builder.String()
 }
 
 func (m Model) StatusBarView() string {
+	if len(m.marked) != 0 {
+		return statusbarStyle.Render(fmt.Sprintf("%d results, %d marked", len(m.keys), len(m.marked)))
+	}
 	return statusbarStyle.Render(fmt.Sprintf("%d results", len(m.keys)))
 }
 
+// Typing search, editing in dialog or value view needs every key press.
+func (m Model) CapturingInput() bool {
+	return m.input.Focused() || m.dialog.stage != dialogClosed || m.value.stage != valueClosed
+}
+
 func (m Model) Init() tea.Cmd {
 	return textinput.Blink
 }
@@ -77,31 +154,121 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case msgs.RedisStateMsg:
 		m.rdb = msg.Client
+		m.readonly = msg.ReadOnly
 		return m, nil
 
 	case keyUpdateMessage:
 		m.keys = msg.Keys
 		m.err = msg.Err
+		if msg.Err != nil {
+			return m, notify.Err(notifySource, fmt.Errorf("list keys: %w", msg.Err))
+		}
+		listed := func() tea.Msg { return msgs.KeysListedMsg{Keys: msg.Keys} }
+		if m.treeMode {
+			m.tree.build(m.keys, m.lastValue)
+			m.tree.scroll(m.pageSize - noticeHeight)
+			return m.scroll(), tea.Batch(listed, m.tree.measureVisible(m.rdb))
+		}
+		return m.scroll(), listed
+
+	case memoryMsg:
+		m.tree.memory[msg.Node] = msg.Usage
 		return m, nil
 
+	case namespaceMsg:
+		if msg.Err != nil {
+			return m, notify.Err(notifySource, fmt.Errorf("scan %s: %w", msg.Prefix, msg.Err))
+		}
+		m.tree.setNamespace(msg.Prefix, msg.Keys)
+		m.tree.scroll(m.pageSize - noticeHeight)
+		// Keys created since listed can be marked too, keep them in key list.
+		m.keys = slices.Compact(slices.Sorted(slices.Values(append(m.keys, msg.Keys...))))
+		return m.scroll(), m.tree.measureVisible(m.rdb)
+
+	case previewMsg:
+		var c tea.Cmd
+		m.dialog, c = m.dialog.Update(msg, m.rdb)
+		if msg.Err != nil {
+			c = tea.Batch(c, notify.Err(notifySource, fmt.Errorf("preview: %w", msg.Err)))
+		}
+		return m, c
+
+	case valueLoadedMsg, valueSavedMsg, requeue.LoadedMsg, requeue.DoneMsg:
+		var c tea.Cmd
+		m.value, c = m.value.Update(msg, m.rdb, m.readonly)
+		return m, c
+
+	case actionDoneMsg:
+		m.dialog.stage = dialogClosed
+		m.marked = make(map[string]bool)
+		query := queryKeysCmd(m.rdb, m.lastValue)
+		if msg.Err != nil {
+			m.notice = fmt.Sprintf("%s failed after %d key(s): %s", msg.Action, len(msg.Results), msg.Err.Error())
+			return m, tea.Batch(query, notify.Err(notifySource, fmt.Errorf("%s: %w", msg.Action, msg.Err)))
+		}
+		m.notice = fmt.Sprintf("%s done on %d key(s)", msg.Action, len(msg.Results))
+		return m, query
+
 	case tea.WindowSizeMsg:
 		m.pageSize = msg.Height - textInputHeght
+		m.width = msg.Width
+		m.tree.scroll(m.pageSize - noticeHeight)
+		m.value = m.value.setHeight(msg.Height)
+		return m.scroll(), nil
+
+	case tea.MouseMsg:
+		if m.dialog.stage != dialogClosed || m.value.stage != valueClosed {
+			return m, nil
+		}
+		return m.handleMouse(msg)
+
+	case paletteMsg, action:
+		if m.dialog.stage != dialogClosed || m.value.stage != valueClosed {
+			return m, nil
+		}
+		switch msg {
+		case paletteSearch:
+			return m, m.input.Focus()
+		case paletteTree:
+			m.input.Blur()
+			return m.toggleTree()
+		}
+		if a, ok := msg.(action); ok {
+			m.input.Blur()
+			return m.openDialog(a)
+		}
 		return m, nil
 
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "up":
-			if m.csr > 0 {
-				m.csr--
-			}
-			return m, nil
-		case "down":
-			if m.csr < len(m.keys)-(m.pageSize/2) {
-				m.csr++
+		if m.dialog.stage != dialogClosed {
+			var c tea.Cmd
+			m.dialog, c = m.dialog.Update(msg, m.rdb)
+			return m, c
+		}
+		if m.value.stage != valueClosed {
+			var c tea.Cmd
+			m.value, c = m.value.Update(msg, m.rdb, m.readonly)
+			return m, c
+		}
+
+		if m.treeMode && !m.input.Focused() {
+			if next, c, ok := m.handleTreeKey(msg); ok {
+				return next, c
 			}
-			return m, nil
-		case "enter":
+		}
+
+		switch {
+		case key.Matches(msg, m.bindings.Query):
+			// Leave search input so keys can be selected and acted on.
+			m.input.Blur()
 			return m, queryKeysCmd(m.rdb, m.input.Value())
+		case m.input.Focused() && key.Matches(msg, m.bindings.Back):
+			m.input.Blur()
+			return m, nil
+		}
+
+		if !m.input.Focused() {
+			return m.handleListKey(msg)
 		}
 	}
 
@@ -112,7 +279,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.input.Value() == "" {
 			m.keys = []string{}
 			m.err = nil
-			m.csr = 0
+			m.list = m.list.SetCursor(0).SetCount(0)
 			return m, c
 		}
 		if m.input.Value() == "*" {
@@ -127,6 +294,200 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, c
 }
 
+// Handle keys when list has focus.
+func (m Model) handleListKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.notice = ""
+
+	if list, ok := m.list.Update(msg); ok {
+		m.list = list
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(msg, m.bindings.Search):
+		return m, m.input.Focus()
+	case key.Matches(msg, m.bindings.Mark):
+		if len(m.keys) == 0 {
+			return m, nil
+		}
+		key := m.keys[m.list.Cursor()]
+		if m.marked[key] {
+			delete(m.marked, key)
+		} else {
+			m.marked[key] = true
+		}
+		m.list = m.list.SetCursor(m.list.Cursor() + 1)
+		return m, nil
+	case key.Matches(msg, m.bindings.Tree):
+		return m.toggleTree()
+	case key.Matches(msg, m.bindings.MarkAll):
+		if len(m.marked) != 0 {
+			m.marked = make(map[string]bool)
+		} else {
+			for _, key := range m.keys {
+				m.marked[key] = true
+			}
+		}
+		return m, nil
+	case key.Matches(msg, m.bindings.View):
+		if len(m.keys) == 0 {
+			return m, nil
+		}
+		keys := m.cursorKeys()
+		if len(keys) != 1 || (m.treeMode && !m.tree.selected().leaf) {
+			m.notice = "Select a single key to view its value."
+			return m, nil
+		}
+		m.value = newValueView(keys[0], m.bindings, m.pageSize+textInputHeght)
+		return m, loadValue(m.rdb, keys[0])
+	case key.Matches(msg, m.bindings.Del):
+		return m.openDialog(actionDel)
+	case key.Matches(msg, m.bindings.Unlink):
+		return m.openDialog(actionUnlink)
+	case key.Matches(msg, m.bindings.Expire):
+		return m.openDialog(actionExpire)
+	case key.Matches(msg, m.bindings.Persist):
+		return m.openDialog(actionPersist)
+	case key.Matches(msg, m.bindings.Rename):
+		return m.openDialog(actionRename)
+	}
+
+	return m, nil
+}
+
+func (m Model) toggleTree() (tea.Model, tea.Cmd) {
+	m.treeMode = !m.treeMode
+	if m.treeMode {
+		m.tree.build(m.keys, m.lastValue)
+		return m, m.tree.measureVisible(m.rdb)
+	}
+	return m, nil
+}
+
+// Wheel moves cursor, click on input focuses it, click on row selects it.
+func (m Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	list := &m.list
+	if m.treeMode {
+		list = &m.tree.list
+	}
+
+	if next, ok := list.Update(msg); ok {
+		*list = next
+	} else if msg.Button == tea.MouseButtonLeft && msg.Action == tea.MouseActionPress {
+		if msg.Y < textInputHeght {
+			return m, m.input.Focus()
+		}
+		next, ok := list.Click(msg.Y - textInputHeght)
+		if !ok {
+			return m, nil
+		}
+		m.input.Blur()
+		*list = next
+	}
+
+	if m.treeMode {
+		return m, m.tree.measureVisible(m.rdb)
+	}
+	return m, nil
+}
+
+// Open dialog of action on marked keys, or key under cursor if nothing marked.
+func (m Model) openDialog(a action) (tea.Model, tea.Cmd) {
+	if len(m.keys) == 0 {
+		return m, nil
+	}
+	if m.readonly {
+		m.notice = fmt.Sprintf("%s is disabled in read-only mode, restart without -readonly or use a non-production profile.", a)
+		return m, nil
+	}
+
+	keys := make([]string, 0, len(m.marked))
+	for _, key := range m.keys {
+		if m.marked[key] {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		keys = m.cursorKeys()
+	}
+	if a == actionRename && len(keys) != 1 {
+		m.notice = "RENAME works on one key only, unmark others first."
+		return m, nil
+	}
+
+	var c tea.Cmd
+	m.dialog, c = newDialog(a, keys, m.rdb, m.bindings)
+	return m, c
+}
+
+// Keys under cursor, every key of the node in tree mode.
+func (m Model) cursorKeys() []string {
+	if !m.treeMode {
+		return []string{m.keys[m.list.Cursor()]}
+	}
+	if n := m.tree.selected(); n != nil {
+		return append([]string(nil), n.keys...)
+	}
+	return nil
+}
+
+// Handle navigation keys in tree mode, ok is false if key is not for tree.
+func (m Model) handleTreeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	m.notice = ""
+	height := m.pageSize - noticeHeight
+
+	if list, ok := m.tree.list.Update(msg); ok {
+		m.tree.list = list
+		return m, m.tree.measureVisible(m.rdb), true
+	}
+
+	switch {
+	case key.Matches(msg, m.bindings.Toggle, m.bindings.Expand, m.bindings.Fold):
+		n := m.tree.selected()
+		if n == nil || n.leaf {
+			return m, nil, true
+		}
+		wasExpanded := m.tree.expanded[n.prefix]
+		switch {
+		case key.Matches(msg, m.bindings.Toggle):
+			m.tree.expanded[n.prefix] = !m.tree.expanded[n.prefix]
+		case key.Matches(msg, m.bindings.Expand):
+			m.tree.expanded[n.prefix] = true
+		case key.Matches(msg, m.bindings.Fold):
+			delete(m.tree.expanded, n.prefix)
+		}
+		m.tree.scroll(height)
+		// Children show keys listed before until namespace is scanned.
+		if m.tree.expanded[n.prefix] && !wasExpanded {
+			return m, tea.Batch(m.tree.measureVisible(m.rdb), m.tree.loadNamespace(m.rdb, n.prefix)), true
+		}
+		return m, m.tree.measureVisible(m.rdb), true
+	case key.Matches(msg, m.bindings.Mark):
+		keys := m.cursorKeys()
+		if allMarked(keys, m.marked) {
+			for _, key := range keys {
+				delete(m.marked, key)
+			}
+		} else {
+			for _, key := range keys {
+				m.marked[key] = true
+			}
+		}
+		m.tree.list = m.tree.list.SetCursor(m.tree.list.Cursor() + 1)
+	default:
+		return m, nil, false
+	}
+
+	m.tree.scroll(height)
+	return m, nil, true
+}
+
+// Fit list to keys and page height.
+func (m Model) scroll() Model {
+	m.list = m.list.SetCount(len(m.keys)).SetHeight(m.pageSize - noticeHeight)
+	return m
+}
+
 type keyUpdateMessage struct {
 	Keys []string
 	Err  error
@@ -134,7 +495,7 @@ type keyUpdateMessage struct {
 
 func queryKeysCmd(rdb *redis.Client, patten string) tea.Cmd {
 	return func() tea.Msg {
-		keys, err := rdb.Keys(context.Background(), patten).Result()
+		keys, err := scanKeys(context.Background(), rdb, patten)
 		return keyUpdateMessage{Keys: keys, Err: err}
 	}
 }
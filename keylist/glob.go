@@ -0,0 +1,114 @@
+package keylist
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Keys asked for on each SCAN call.
+const scanBatch = 1000
+
+// Every key matching pattern. SCAN is used instead of KEYS so a big keyspace
+// doesn't block the server, a key SCAN returns twice is kept once.
+func scanKeys(ctx context.Context, rdb *redis.Client, pattern string) ([]string, error) {
+	keys := make([]string, 0)
+	var cursor uint64
+	for {
+		page, next, err := rdb.Scan(ctx, cursor, pattern, scanBatch).Result()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, page...)
+		if next == 0 {
+			break
+		}
+		cursor = next
+	}
+	sort.Strings(keys)
+	return slices.Compact(keys), nil
+}
+
+// Escape glob special characters so s matches only itself.
+func globEscape(s string) string {
+	var builder strings.Builder
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(`*?[]\`, s[i]) >= 0 {
+			builder.WriteByte('\\')
+		}
+		builder.WriteByte(s[i])
+	}
+	return builder.String()
+}
+
+// Whether key matches glob pattern, same rules as KEYS and SCAN MATCH.
+func globMatch(pattern, key string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			pattern = strings.TrimLeft(pattern, "*")
+			if pattern == "" {
+				return true
+			}
+			for i := 0; i <= len(key); i++ {
+				if globMatch(pattern, key[i:]) {
+					return true
+				}
+			}
+			return false
+
+		case '?':
+			if key == "" {
+				return false
+			}
+
+		case '[':
+			if key == "" {
+				return false
+			}
+			p := pattern[1:]
+			negate := strings.HasPrefix(p, "^")
+			if negate {
+				p = p[1:]
+			}
+			matched := false
+			for len(p) > 0 && p[0] != ']' {
+				switch {
+				case p[0] == '\\' && len(p) > 1:
+					matched = matched || p[1] == key[0]
+					p = p[2:]
+				case len(p) > 2 && p[1] == '-' && p[2] != ']':
+					lo, hi := min(p[0], p[2]), max(p[0], p[2])
+					matched = matched || (lo <= key[0] && key[0] <= hi)
+					p = p[3:]
+				default:
+					matched = matched || p[0] == key[0]
+					p = p[1:]
+				}
+			}
+			if matched == negate {
+				return false
+			}
+			// Skip closing bracket, rest of pattern is matched after it.
+			pattern = strings.TrimPrefix(p, "]")
+			key = key[1:]
+			continue
+
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+
+		default:
+			if key == "" || key[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern, key = pattern[1:], key[1:]
+	}
+	return key == ""
+}
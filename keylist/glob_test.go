@@ -0,0 +1,41 @@
+package keylist
+
+import "testing"
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		key     string
+		want    bool
+	}{
+		{"*", "", true},
+		{"*", "task::1", true},
+		{"*task*", "gw::task::1", true},
+		{"*task*", "gw::runner", false},
+		{"task::?", "task::1", true},
+		{"task::?", "task::12", false},
+		{"task::[0-9]", "task::7", true},
+		{"task::[^0-9]", "task::7", false},
+		{"task::[ab]", "task::b", true},
+		{`a\*b`, "a*b", true},
+		{`a\*b`, "axb", false},
+		{"a*b*c", "aXbYc", true},
+		{"a*b*c", "aXbY", false},
+	}
+	for _, tt := range tests {
+		if got := globMatch(tt.pattern, tt.key); got != tt.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", tt.pattern, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestGlobEscape(t *testing.T) {
+	for _, s := range []string{"plain::", "a*b?", "[x]::", `back\slash`} {
+		if !globMatch(globEscape(s)+"*", s+"rest") {
+			t.Errorf("escaped %q does not match itself", s)
+		}
+	}
+	if globMatch(globEscape("a*")+"*", "abc") {
+		t.Error(`escaped "a*" matches "abc"`)
+	}
+}
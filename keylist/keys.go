@@ -0,0 +1,138 @@
+package keylist
+
+import (
+	"gw/dispatcher/debugger/keymap"
+	"gw/dispatcher/debugger/tab"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+)
+
+type keyMap struct {
+	keymap.Common
+
+	// Search input.
+	Search key.Binding
+	Query  key.Binding
+
+	// Key list and tree.
+	Mark    key.Binding
+	MarkAll key.Binding
+	Tree    key.Binding
+	View    key.Binding
+	Del     key.Binding
+	Unlink  key.Binding
+	Expire  key.Binding
+	Persist key.Binding
+	Rename  key.Binding
+	Toggle  key.Binding
+	Expand  key.Binding
+	Fold    key.Binding
+
+	// Dialog and value view.
+	Submit  key.Binding
+	Edit    key.Binding
+	Reload  key.Binding
+	Save    key.Binding
+	Requeue key.Binding
+}
+
+func newKeyMap() keyMap {
+	return keyMap{
+		Common:  keymap.NewCommon(),
+		Search:  keymap.New("keys.search", "search", "/"),
+		Query:   keymap.New("keys.query", "run search", "enter"),
+		Mark:    keymap.New("keys.mark", "mark", " "),
+		MarkAll: keymap.New("keys.mark_all", "mark all/none", "a"),
+		Tree:    keymap.New("keys.tree", "toggle tree", "t"),
+		View:    keymap.New("keys.view", "view value", "v"),
+		Del:     keymap.New("keys.del", "DEL", "d"),
+		Unlink:  keymap.New("keys.unlink", "UNLINK", "u"),
+		Expire:  keymap.New("keys.expire", "EXPIRE", "e"),
+		Persist: keymap.New("keys.persist", "PERSIST", "p"),
+		Rename:  keymap.New("keys.rename", "RENAME", "r"),
+		Toggle:  keymap.New("keys.toggle", "expand/fold", "enter"),
+		Expand:  keymap.New("keys.expand", "expand", "right"),
+		Fold:    keymap.New("keys.fold", "fold", "left"),
+		Submit:  keymap.New("keys.submit", "preview", "enter"),
+		Edit:    keymap.New("keys.edit", "edit", "e", "enter"),
+		Reload:  keymap.New("keys.reload", "reload", "r"),
+		Save:    keymap.New("keys.save", "preview change", "ctrl+s"),
+		Requeue: keymap.New("keys.requeue", "requeue entry", "a"),
+	}
+}
+
+// Use to run palette action, key actions use action.
+type paletteMsg int
+
+const (
+	paletteSearch paletteMsg = iota
+	paletteTree
+)
+
+func (m Model) Actions() []tab.Action {
+	result := []tab.Action{
+		{Title: "Search keys", Msg: paletteSearch},
+		{Title: "Toggle key tree", Msg: paletteTree},
+	}
+	for _, a := range []action{actionDel, actionUnlink, actionExpire, actionPersist, actionRename} {
+		result = append(result, tab.Action{Title: a.String() + " marked or selected keys", Msg: a})
+	}
+	return result
+}
+
+func (m Model) Help() help.KeyMap {
+	k := m.bindings
+	confirm := keymap.Help{Short: []key.Binding{k.Confirm, k.Cancel}, Full: [][]key.Binding{{k.Confirm, k.Cancel}}}
+
+	switch m.dialog.stage {
+	case dialogInput:
+		return keymap.Help{Short: []key.Binding{k.Submit, k.Back}, Full: [][]key.Binding{{k.Submit, k.Back}}}
+	case dialogPreview:
+		return confirm
+	}
+
+	switch m.value.stage {
+	case valueLoading:
+		return keymap.Help{Short: []key.Binding{k.Back}, Full: [][]key.Binding{{k.Back}}}
+	case valueBrowsing:
+		edit := k.Edit
+		if m.value.typ == "stream" {
+			edit = k.Requeue
+		}
+		return keymap.Help{
+			Short: []key.Binding{k.Up, k.Down, edit, k.Reload, k.Back},
+			Full:  [][]key.Binding{{k.Up, k.Down}, {edit, k.Reload, k.Back}},
+		}
+	case valueEditing:
+		return keymap.Help{Short: []key.Binding{k.Save, k.Back}, Full: [][]key.Binding{{k.Save, k.Back}}}
+	case valueDiff:
+		return confirm
+	case valueRequeue:
+		return m.value.requeue.Help()
+	}
+
+	if m.input.Focused() {
+		return keymap.Help{Short: []key.Binding{k.Query, k.Back}, Full: [][]key.Binding{{k.Query, k.Back}}}
+	}
+
+	actions := []key.Binding{k.View, k.Del, k.Unlink, k.Expire, k.Persist, k.Rename}
+	if m.treeMode {
+		return keymap.Help{
+			Short: []key.Binding{k.Search, k.Toggle, k.Mark, k.Tree, k.View, k.Del},
+			Full: [][]key.Binding{
+				m.tree.list.Bindings(),
+				{k.Toggle, k.Expand, k.Fold, k.Search, k.Mark, k.MarkAll, k.Tree},
+				actions,
+			},
+		}
+	}
+	return keymap.Help{
+		Short: []key.Binding{k.Search, k.Mark, k.Tree, k.View, k.Del},
+		Full: [][]key.Binding{
+			m.list.Bindings(),
+			{k.Search, k.Query, k.Mark, k.MarkAll, k.Tree},
+			actions,
+		},
+	}
+}
@@ -0,0 +1,329 @@
+package keylist
+
+import (
+	"context"
+	"fmt"
+	"gw/dispatcher/debugger/listview"
+	"gw/dispatcher/debugger/style"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	treeLabelStyle  = style.W().XL
+	treeCountStyle  = style.W().S.Align(lipgloss.Right)
+	treeMemoryStyle = style.W().M.Align(lipgloss.Right)
+)
+
+// Default separator of key namespace.
+const DefaultSeparator = "::"
+
+// At most this many keys are measured for memory of one node.
+const memorySampleLimit = 500
+
+// One node in key tree, either a namespace or a key.
+type node struct {
+	// Namespace with trailing separator, or the full key of leaf.
+	prefix string
+	label  string
+	depth  int
+	leaf   bool
+
+	// Every key under this node, sorted. Keys of a namespace are scanned
+	// again when it's expanded.
+	keys []string
+
+	// Built when node is expanded the first time.
+	children []*node
+}
+
+// Tell a namespace from a key of same name, e.g. key "a:" and namespace "a:".
+type nodeID struct {
+	prefix string
+	leaf   bool
+}
+
+func (n *node) id() nodeID {
+	return nodeID{prefix: n.prefix, leaf: n.leaf}
+}
+
+// Memory used by keys of a node.
+type memoryUsage struct {
+	Bytes int64
+
+	// Only part of keys measured, real usage is larger.
+	Partial bool
+	Err     error
+}
+
+type memoryMsg struct {
+	Node  nodeID
+	Usage memoryUsage
+}
+
+// Use to deliver keys of an expanded namespace.
+type namespaceMsg struct {
+	Prefix string
+	Keys   []string
+	Err    error
+}
+
+// Key tree grouped by separator, children are grouped lazily on expand.
+type tree struct {
+	sep string
+	// Pattern keys are listed by, keys of namespace scanned on expand match it too.
+	pattern  string
+	roots    []*node
+	expanded map[string]bool
+	memory   map[nodeID]memoryUsage
+
+	// Selected node and viewport of visible nodes.
+	list listview.Model
+}
+
+func newTree(sep string) tree {
+	return tree{
+		sep:      sep,
+		expanded: make(map[string]bool),
+		memory:   make(map[nodeID]memoryUsage),
+		list:     listview.New(),
+	}
+}
+
+// Rebuild tree from keys listed by pattern, expanded nodes stay expanded.
+func (t *tree) build(keys []string, pattern string) {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+	t.pattern = pattern
+	t.roots = group("", 0, sorted, t.sep)
+	t.memory = make(map[nodeID]memoryUsage)
+	t.list = t.list.SetCount(len(t.visible()))
+}
+
+// Command scan keys of namespace which also match pattern of tree.
+func (t *tree) loadNamespace(rdb *redis.Client, prefix string) tea.Cmd {
+	pattern := t.pattern
+	return func() tea.Msg {
+		keys, err := scanKeys(context.Background(), rdb, globEscape(prefix)+"*")
+		if err != nil {
+			return namespaceMsg{Prefix: prefix, Err: err}
+		}
+		result := namespaceMsg{Prefix: prefix, Keys: make([]string, 0, len(keys))}
+		for _, key := range keys {
+			if globMatch(pattern, key) {
+				result.Keys = append(result.Keys, key)
+			}
+		}
+		return result
+	}
+}
+
+// Replace keys of namespace with scanned ones, its children are grouped again
+// and memory under it measured again.
+func (t *tree) setNamespace(prefix string, keys []string) {
+	var find func(nodes []*node) *node
+	find = func(nodes []*node) *node {
+		for _, n := range nodes {
+			if n.leaf || !strings.HasPrefix(prefix, n.prefix) {
+				continue
+			}
+			if n.prefix == prefix {
+				return n
+			}
+			return find(n.children)
+		}
+		return nil
+	}
+	n := find(t.roots)
+	if n == nil {
+		return
+	}
+
+	n.keys = keys
+	n.children = nil
+	for id := range t.memory {
+		if strings.HasPrefix(id.prefix, prefix) {
+			delete(t.memory, id)
+		}
+	}
+}
+
+// Group keys which all start with prefix into child nodes.
+func group(prefix string, depth int, keys []string, sep string) []*node {
+	result := make([]*node, 0)
+	index := make(map[string]*node)
+
+	for _, key := range keys {
+		rest := key[len(prefix):]
+		seg, _, found := strings.Cut(rest, sep)
+		if !found {
+			result = append(result, &node{prefix: key, label: rest, depth: depth, leaf: true, keys: []string{key}})
+			continue
+		}
+
+		p := prefix + seg + sep
+		n, ok := index[p]
+		if !ok {
+			n = &node{prefix: p, label: seg + sep, depth: depth}
+			index[p] = n
+			result = append(result, n)
+		}
+		n.keys = append(n.keys, key)
+	}
+	return result
+}
+
+// Nodes shown in order, children of expanded nodes included.
+func (t *tree) visible() []*node {
+	result := make([]*node, 0)
+	var walk func(nodes []*node)
+	walk = func(nodes []*node) {
+		for _, n := range nodes {
+			result = append(result, n)
+			if n.leaf || !t.expanded[n.prefix] {
+				continue
+			}
+			if n.children == nil {
+				n.children = group(n.prefix, n.depth+1, n.keys, t.sep)
+			}
+			walk(n.children)
+		}
+	}
+	walk(t.roots)
+	return result
+}
+
+func (t *tree) selected() *node {
+	nodes := t.visible()
+	if t.list.Cursor() >= len(nodes) {
+		return nil
+	}
+	return nodes[t.list.Cursor()]
+}
+
+// Commands measure memory of visible nodes not measured yet.
+func (t *tree) measureVisible(rdb *redis.Client) tea.Cmd {
+	cmds := make([]tea.Cmd, 0)
+	for _, n := range t.visible() {
+		if _, ok := t.memory[n.id()]; ok {
+			continue
+		}
+		// Mark as pending so it's measured only once.
+		t.memory[n.id()] = memoryUsage{Bytes: -1}
+		cmds = append(cmds, measureMemory(rdb, n.id(), n.keys))
+	}
+	return tea.Batch(cmds...)
+}
+
+// Command sum MEMORY USAGE of keys, only a sample of a big node is measured.
+func measureMemory(rdb *redis.Client, id nodeID, keys []string) tea.Cmd {
+	return func() tea.Msg {
+		result := memoryMsg{Node: id}
+
+		sample := keys
+		if len(sample) > memorySampleLimit {
+			sample = sample[:memorySampleLimit]
+			result.Usage.Partial = true
+		}
+
+		pipe := rdb.Pipeline()
+		cmds := make([]*redis.IntCmd, len(sample))
+		for i, key := range sample {
+			cmds[i] = pipe.MemoryUsage(context.Background(), key)
+		}
+		if _, err := pipe.Exec(context.Background()); err != nil && err != redis.Nil {
+			result.Usage.Err = err
+			return result
+		}
+
+		for _, c := range cmds {
+			result.Usage.Bytes += c.Val()
+		}
+		return result
+	}
+}
+
+// Fit list to visible nodes and page height.
+func (t *tree) scroll(height int) {
+	t.list = t.list.SetCount(len(t.visible())).SetHeight(height)
+}
+
+func (t *tree) view(marked map[string]bool) string {
+	nodes := t.visible()
+	if len(nodes) == 0 {
+		return "No keys."
+	}
+
+	var builder strings.Builder
+	start, end := t.list.Range()
+	for i := start; i < min(end, len(nodes)); i++ {
+		n := nodes[i]
+
+		icon := "  "
+		if !n.leaf {
+			if t.expanded[n.prefix] {
+				icon = "▾ "
+			} else {
+				icon = "▸ "
+			}
+		}
+
+		mark := " "
+		if allMarked(n.keys, marked) {
+			mark = "*"
+		}
+
+		label := treeLabelStyle.Render(mark + strings.Repeat("  ", n.depth) + icon + n.label)
+		if allMarked(n.keys, marked) {
+			label = markedStyle.Inherit(treeLabelStyle).Render(mark + strings.Repeat("  ", n.depth) + icon + n.label)
+		}
+
+		count := ""
+		if !n.leaf {
+			count = fmt.Sprintf("%d", len(n.keys))
+		}
+
+		usage, ok := t.memory[n.id()]
+		if !ok {
+			usage.Bytes = -1
+		}
+
+		line := label + treeCountStyle.Render(count) + treeMemoryStyle.Render(formatMemory(usage))
+		if i == t.list.Cursor() {
+			line = cursorStyle.Render(line)
+		}
+		builder.WriteString(line + "\n")
+	}
+	return builder.String()
+}
+
+func allMarked(keys []string, marked map[string]bool) bool {
+	if len(keys) == 0 {
+		return false
+	}
+	for _, key := range keys {
+		if !marked[key] {
+			return false
+		}
+	}
+	return true
+}
+
+func formatMemory(u memoryUsage) string {
+	switch {
+	case u.Err != nil:
+		return "error"
+	case u.Bytes < 0:
+		return "..."
+	}
+
+	text := style.Bytes(u.Bytes)
+	if u.Partial {
+		text = ">" + text
+	}
+	return text
+}
@@ -0,0 +1,594 @@
+package keylist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gw/dispatcher/debugger/keymap"
+	"gw/dispatcher/debugger/notify"
+	"gw/dispatcher/debugger/requeue"
+	"gw/dispatcher/debugger/style"
+	"gw/dispatcher/debugger/theme"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/redis/go-redis/v9"
+)
+
+var valueFieldStyle = style.W().L
+
+// Rebuilt on theme change.
+var (
+	diffAdd lipgloss.Style
+	diffDel lipgloss.Style
+)
+
+func init() {
+	theme.OnChange(func(t theme.Theme) {
+		diffAdd = t.Fg(t.Success)
+		diffDel = t.Fg(t.Error)
+	})
+}
+
+// At most this many items of a collection are loaded.
+const maxValueItems = 1000
+
+// Returned when key changed after it's loaded.
+var errConflict = errors.New("value changed by someone else since loaded, reload and edit again")
+
+type valueStage int
+
+const (
+	valueClosed valueStage = iota
+	valueLoading
+	valueBrowsing
+	valueEditing
+	valueDiff
+	valueSaving
+	valueRequeue
+)
+
+// One editable piece of a value.
+type valueItem struct {
+	// Hash field, list index, set or zset member, stream entry ID, empty for string.
+	Field string
+
+	// String value, hash value, list item, set member, zset score or
+	// fields of stream entry as JSON.
+	Value string
+}
+
+type valueLoadedMsg struct {
+	Key   string
+	Type  string
+	Items []valueItem
+	Err   error
+}
+
+type valueSavedMsg struct {
+	Key string
+	Err error
+}
+
+// View and edit value of one key.
+type valueView struct {
+	bindings keyMap
+	stage    valueStage
+	key      string
+	typ      string
+	items    []valueItem
+
+	height int
+	csr    int
+	offset int
+
+	editor textarea.Model
+	edited string
+
+	requeue requeue.Model
+
+	err    error
+	notice string
+}
+
+func newValueView(key string, bindings keyMap, height int) valueView {
+	editor := textarea.New()
+	editor.ShowLineNumbers = true
+	editor.SetWidth(80)
+	editor.SetHeight(12)
+	editor.CharLimit = 0
+
+	return valueView{bindings: bindings, stage: valueLoading, key: key, editor: editor, height: height}
+}
+
+// Command read value of key.
+func loadValue(rdb *redis.Client, key string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		result := valueLoadedMsg{Key: key}
+
+		result.Type, result.Err = rdb.Type(ctx, key).Result()
+		if result.Err != nil {
+			return result
+		}
+
+		switch result.Type {
+		case "none":
+			result.Err = fmt.Errorf("key %s not exists", key)
+
+		case "string":
+			var v string
+			v, result.Err = rdb.Get(ctx, key).Result()
+			result.Items = []valueItem{{Value: v}}
+
+		case "hash":
+			var kv map[string]string
+			kv, result.Err = rdb.HGetAll(ctx, key).Result()
+			for k, v := range kv {
+				result.Items = append(result.Items, valueItem{Field: k, Value: v})
+			}
+			sort.Slice(result.Items, func(i, j int) bool { return result.Items[i].Field < result.Items[j].Field })
+
+		case "list":
+			var items []string
+			items, result.Err = rdb.LRange(ctx, key, 0, maxValueItems-1).Result()
+			for i, v := range items {
+				result.Items = append(result.Items, valueItem{Field: strconv.Itoa(i), Value: v})
+			}
+
+		case "set":
+			var members []string
+			members, result.Err = rdb.SMembers(ctx, key).Result()
+			sort.Strings(members)
+			for _, v := range members {
+				result.Items = append(result.Items, valueItem{Field: v, Value: v})
+			}
+
+		case "zset":
+			var members []redis.Z
+			members, result.Err = rdb.ZRangeWithScores(ctx, key, 0, maxValueItems-1).Result()
+			for _, z := range members {
+				result.Items = append(result.Items, valueItem{
+					Field: fmt.Sprint(z.Member),
+					Value: strconv.FormatFloat(z.Score, 'f', -1, 64),
+				})
+			}
+
+		case "stream":
+			var messages []redis.XMessage
+			messages, result.Err = rdb.XRangeN(ctx, key, "-", "+", maxValueItems).Result()
+			for _, msg := range messages {
+				fields := make(map[string]string, len(msg.Values))
+				for k, v := range msg.Values {
+					fields[k] = fmt.Sprint(v)
+				}
+				data, _ := json.Marshal(fields)
+				result.Items = append(result.Items, valueItem{Field: msg.ID, Value: string(data)})
+			}
+
+		default:
+			result.Err = fmt.Errorf("editing %s is not supported", result.Type)
+		}
+
+		if len(result.Items) > maxValueItems {
+			result.Items = result.Items[:maxValueItems]
+		}
+		return result
+	}
+}
+
+// Command write one item back, fail if it has changed since loaded.
+func saveValue(rdb *redis.Client, key, typ string, item valueItem, newValue string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+
+		err := rdb.Watch(ctx, func(tx *redis.Tx) error {
+			// WATCH only catch changes after it, compare with loaded value for earlier ones.
+			current, err := readItem(ctx, tx, key, typ, item.Field)
+			if err != nil {
+				return err
+			}
+			if current != item.Value {
+				return errConflict
+			}
+
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				switch typ {
+				case "string":
+					pipe.SetArgs(ctx, key, newValue, redis.SetArgs{KeepTTL: true})
+				case "hash":
+					pipe.HSet(ctx, key, item.Field, newValue)
+				case "list":
+					index, _ := strconv.ParseInt(item.Field, 10, 64)
+					pipe.LSet(ctx, key, index, newValue)
+				case "set":
+					pipe.SRem(ctx, key, item.Value)
+					pipe.SAdd(ctx, key, newValue)
+				case "zset":
+					score, _ := strconv.ParseFloat(newValue, 64)
+					pipe.ZAdd(ctx, key, redis.Z{Score: score, Member: item.Field})
+				}
+				return nil
+			})
+			return err
+		}, key)
+
+		if errors.Is(err, redis.TxFailedErr) {
+			err = errConflict
+		}
+		return valueSavedMsg{Key: key, Err: err}
+	}
+}
+
+// Read current value of one item inside a WATCH.
+func readItem(ctx context.Context, tx *redis.Tx, key, typ, field string) (value string, err error) {
+	// Key or field is deleted since loaded.
+	defer func() {
+		if errors.Is(err, redis.Nil) {
+			err = errConflict
+		}
+	}()
+
+	switch typ {
+	case "string":
+		return tx.Get(ctx, key).Result()
+	case "hash":
+		return tx.HGet(ctx, key, field).Result()
+	case "list":
+		index, _ := strconv.ParseInt(field, 10, 64)
+		return tx.LIndex(ctx, key, index).Result()
+	case "set":
+		ok, err := tx.SIsMember(ctx, key, field).Result()
+		if err != nil || !ok {
+			return "", err
+		}
+		return field, nil
+	case "zset":
+		score, err := tx.ZScore(ctx, key, field).Result()
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatFloat(score, 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("editing %s is not supported", typ)
+}
+
+// Check edited value, JSON must stay JSON.
+func validateValue(typ, old, value string) error {
+	if typ == "zset" {
+		if _, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err != nil {
+			return fmt.Errorf("score must be a number: %w", err)
+		}
+		return nil
+	}
+
+	trimmed := strings.TrimSpace(old)
+	isJSON := (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) && json.Valid([]byte(old))
+	if isJSON && !json.Valid([]byte(value)) {
+		var v interface{}
+		err := json.Unmarshal([]byte(value), &v)
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return nil
+}
+
+func (v valueView) Update(msg tea.Msg, rdb *redis.Client, readonly bool) (valueView, tea.Cmd) {
+	switch msg := msg.(type) {
+	case valueLoadedMsg:
+		if msg.Key != v.key {
+			return v, nil
+		}
+		v.err = msg.Err
+		v.typ = msg.Type
+		v.items = msg.Items
+		v.csr = max(0, min(v.csr, len(v.items)-1))
+		v = v.scroll()
+		if v.err != nil {
+			return v, notify.Err(notifySource, fmt.Errorf("load %s: %w", msg.Key, msg.Err))
+		}
+		v.stage = valueBrowsing
+		return v, nil
+
+	case valueSavedMsg:
+		if msg.Err != nil {
+			v.err = msg.Err
+			v.stage = valueBrowsing
+			return v, notify.Err(notifySource, fmt.Errorf("save %s: %w", msg.Key, msg.Err))
+		}
+		v.notice = "saved"
+		v.stage = valueLoading
+		return v, loadValue(rdb, v.key)
+
+	case requeue.LoadedMsg:
+		var c tea.Cmd
+		v.requeue, c = v.requeue.Update(msg, rdb)
+		if msg.Err != nil {
+			c = tea.Batch(c, notify.Err(notifySource, fmt.Errorf("load %s %s: %w", msg.Entry.Stream, msg.Entry.ID, msg.Err)))
+		}
+		return v, c
+
+	case requeue.DoneMsg:
+		v.notice = msg.Describe()
+		v.requeue = v.requeue.Close()
+		v.stage = valueBrowsing
+		if msg.Err != nil {
+			return v, notify.Err(notifySource, fmt.Errorf("requeue %s %s: %w", msg.Entry.Stream, msg.Entry.ID, msg.Err))
+		}
+		return v, nil
+
+	case tea.KeyMsg:
+		return v.handleKey(msg, rdb, readonly)
+	}
+
+	return v, nil
+}
+
+func (v valueView) handleKey(msg tea.KeyMsg, rdb *redis.Client, readonly bool) (valueView, tea.Cmd) {
+	switch v.stage {
+	case valueLoading:
+		if key.Matches(msg, v.bindings.Back) {
+			v.stage = valueClosed
+		}
+		return v, nil
+
+	case valueRequeue:
+		var c tea.Cmd
+		v.requeue, c = v.requeue.Update(msg, rdb)
+		if !v.requeue.Open() {
+			v.stage = valueBrowsing
+		}
+		return v, c
+
+	case valueBrowsing:
+		v.notice = ""
+		switch {
+		case key.Matches(msg, v.bindings.Back):
+			v.stage = valueClosed
+		case key.Matches(msg, v.bindings.Up):
+			if v.csr > 0 {
+				v.csr--
+			}
+			return v.scroll(), nil
+		case key.Matches(msg, v.bindings.Down):
+			if v.csr < len(v.items)-1 {
+				v.csr++
+			}
+			return v.scroll(), nil
+		case key.Matches(msg, v.bindings.Reload):
+			v.err = nil
+			v.stage = valueLoading
+			return v, loadValue(rdb, v.key)
+		case key.Matches(msg, v.bindings.Edit):
+			if len(v.items) == 0 {
+				return v, nil
+			}
+			if v.typ == "stream" {
+				v.notice = "Stream entries can't be edited, requeue one with edited fields instead."
+				return v, nil
+			}
+			if readonly {
+				v.notice = "Editing is disabled in read-only mode, restart without -readonly or use a non-production profile."
+				return v, nil
+			}
+			v.err = nil
+			v.stage = valueEditing
+			v.editor.SetValue(v.items[v.csr].Value)
+			return v, v.editor.Focus()
+		case key.Matches(msg, v.bindings.Requeue):
+			if v.typ != "stream" || len(v.items) == 0 {
+				return v, nil
+			}
+			if readonly {
+				v.notice = "Requeue is disabled in read-only mode, restart without -readonly or use a non-production profile."
+				return v, nil
+			}
+			entry := requeue.Entry{Stream: v.key, ID: v.items[v.csr].Field}
+			v.stage = valueRequeue
+			v.requeue = requeue.New(entry)
+			return v, requeue.Load(rdb, entry)
+		}
+		return v, nil
+
+	case valueEditing:
+		switch {
+		case key.Matches(msg, v.bindings.Back):
+			v.editor.Blur()
+			v.stage = valueBrowsing
+			return v, nil
+		case key.Matches(msg, v.bindings.Save):
+			edited := v.editor.Value()
+			if v.typ == "zset" {
+				edited = strings.TrimSpace(edited)
+			}
+			if err := validateValue(v.typ, v.items[v.csr].Value, edited); err != nil {
+				v.err = err
+				return v, nil
+			}
+			if edited == v.items[v.csr].Value {
+				v.err = errors.New("nothing changed")
+				return v, nil
+			}
+			v.err = nil
+			v.edited = edited
+			v.stage = valueDiff
+			return v, nil
+		}
+		var c tea.Cmd
+		v.editor, c = v.editor.Update(msg)
+		return v, c
+
+	case valueDiff:
+		switch {
+		case key.Matches(msg, v.bindings.Confirm):
+			v.stage = valueSaving
+			return v, saveValue(rdb, v.key, v.typ, v.items[v.csr], v.edited)
+		case key.Matches(msg, v.bindings.Cancel):
+			v.stage = valueEditing
+		}
+		return v, nil
+	}
+
+	return v, nil
+}
+
+// Set rows the view can show.
+func (v valueView) setHeight(height int) valueView {
+	v.height = height
+	return v.scroll()
+}
+
+// Rows of items shown while browsing.
+func (v valueView) pageSize() int {
+	const titleHeight, footerHeight = 2, 2
+	return max(1, v.height-titleHeight-footerHeight)
+}
+
+// Move view offset so the cursor stays in page.
+func (v valueView) scroll() valueView {
+	size := v.pageSize()
+	if v.csr < v.offset {
+		v.offset = v.csr
+	}
+	if v.csr >= v.offset+size {
+		v.offset = v.csr - size + 1
+	}
+	return v
+}
+
+func (v valueView) View() string {
+	var builder strings.Builder
+	builder.WriteString(dialogTitle.Render(fmt.Sprintf("%s (%s)", v.key, v.typ)) + "\n\n")
+
+	switch v.stage {
+	case valueLoading:
+		if v.err != nil {
+			builder.WriteString(v.err.Error() + "\n\n" + keymap.HelpText(v.bindings.Back))
+		} else {
+			builder.WriteString("Loading...")
+		}
+		return builder.String()
+
+	case valueSaving:
+		builder.WriteString("Saving...")
+		return builder.String()
+
+	case valueRequeue:
+		return v.requeue.View()
+
+	case valueEditing:
+		builder.WriteString(v.itemTitle() + "\n")
+		builder.WriteString(v.editor.View() + "\n")
+		if v.err != nil {
+			builder.WriteString(v.err.Error() + "\n")
+		}
+		builder.WriteString(keymap.HelpText(v.bindings.Save, v.bindings.Back))
+		return builder.String()
+
+	case valueDiff:
+		builder.WriteString(v.itemTitle() + "\n")
+		for _, line := range diffLines(v.items[v.csr].Value, v.edited) {
+			switch line[0] {
+			case '+':
+				builder.WriteString(diffAdd.Render(line) + "\n")
+			case '-':
+				builder.WriteString(diffDel.Render(line) + "\n")
+			default:
+				builder.WriteString(line + "\n")
+			}
+		}
+		builder.WriteString("\n" + confirmText.Render("Write above change? "+keymap.HelpText(v.bindings.Confirm, v.bindings.Cancel)))
+		return builder.String()
+	}
+
+	// Browsing.
+	end := min(v.offset+v.pageSize(), len(v.items))
+	for i := v.offset; i < end; i++ {
+		item := v.items[i]
+		line := strings.ReplaceAll(item.Value, "\n", " ")
+		if v.typ != "string" && v.typ != "set" {
+			line = valueFieldStyle.Render(item.Field) + line
+		}
+		if i == v.csr {
+			line = cursorStyle.Render(line)
+		}
+		builder.WriteString(line + "\n")
+	}
+
+	if v.err != nil {
+		builder.WriteString(v.err.Error() + "\n")
+	} else if v.notice != "" {
+		builder.WriteString(v.notice + "\n")
+	}
+	if v.typ == "stream" {
+		builder.WriteString(keymap.HelpText(v.bindings.Requeue, v.bindings.Reload, v.bindings.Back))
+	} else {
+		builder.WriteString(keymap.HelpText(v.bindings.Edit, v.bindings.Reload, v.bindings.Back))
+	}
+	return builder.String()
+}
+
+// Describe the item being edited.
+func (v *valueView) itemTitle() string {
+	item := v.items[v.csr]
+	switch v.typ {
+	case "hash":
+		return "field " + item.Field
+	case "list":
+		return "index " + item.Field
+	case "set":
+		return "member " + item.Field
+	case "zset":
+		return "score of " + item.Field
+	}
+	return "value"
+}
+
+// Line based diff of old and new text, lines start with "+", "-" or " ".
+func diffLines(oldText, newText string) []string {
+	a, b := strings.Split(oldText, "\n"), strings.Split(newText, "\n")
+
+	// Longest common subsequence table.
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	result := make([]string, 0)
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			result = append(result, " "+a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, "-"+a[i])
+			i++
+		default:
+			result = append(result, "+"+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		result = append(result, "-"+a[i])
+	}
+	for ; j < len(b); j++ {
+		result = append(result, "+"+b[j])
+	}
+	return result
+}
@@ -0,0 +1,74 @@
+package keymap
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// Keys of bindings set by user config, by binding name.
+var overrides = map[string][]string{}
+
+// Set keys from user config, call before any binding is made.
+func SetOverrides(o map[string][]string) {
+	overrides = o
+}
+
+// Binding with default keys, replaced by user config of the same name.
+func New(name string, desc string, keys ...string) key.Binding {
+	if o, ok := overrides[name]; ok && len(o) != 0 {
+		keys = o
+	}
+	names := make([]string, len(keys))
+	for i, k := range keys {
+		names[i] = k
+		if k == " " {
+			names[i] = "space"
+		}
+	}
+	return key.NewBinding(key.WithKeys(keys...), key.WithHelp(strings.Join(names, "/"), desc))
+}
+
+// Keys shared by components.
+type Common struct {
+	Up      key.Binding
+	Down    key.Binding
+	Confirm key.Binding
+	Cancel  key.Binding
+	Back    key.Binding
+}
+
+func NewCommon() Common {
+	return Common{
+		Up:      New("up", "move up", "up"),
+		Down:    New("down", "move down", "down"),
+		Confirm: New("confirm", "yes", "y"),
+		Cancel:  New("cancel", "no", "n", "esc"),
+		Back:    New("back", "back", "esc"),
+	}
+}
+
+// One line help like "y: yes, n/esc: no".
+func HelpText(bindings ...key.Binding) string {
+	parts := make([]string, 0, len(bindings))
+	for _, b := range bindings {
+		if b.Enabled() {
+			parts = append(parts, b.Help().Key+": "+b.Help().Desc)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Bindings for help.Model, what a component shows in current state.
+type Help struct {
+	Short []key.Binding
+	Full  [][]key.Binding
+}
+
+func (h Help) ShortHelp() []key.Binding {
+	return h.Short
+}
+
+func (h Help) FullHelp() [][]key.Binding {
+	return h.Full
+}
@@ -0,0 +1,399 @@
+package keystats
+
+import (
+	"context"
+	"fmt"
+	"gw/dispatcher/debugger/keymap"
+	"gw/dispatcher/debugger/msgs"
+	"gw/dispatcher/debugger/notify"
+	"gw/dispatcher/debugger/style"
+	"gw/dispatcher/debugger/tab"
+	"gw/dispatcher/debugger/theme"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	nameStyle   = style.W().XL
+	countStyle  = style.W().M.Align(lipgloss.Right)
+	memoryStyle = style.W().M.Align(lipgloss.Right)
+	statusStyle = style.W().L.Padding(0, 1)
+)
+
+// Rebuilt on theme change.
+var sectionStyle lipgloss.Style
+
+func init() {
+	theme.OnChange(func(t theme.Theme) {
+		sectionStyle = t.On(t.BackgroundInverse).Bold(true)
+	})
+}
+
+const (
+	// Keys asked for on each SCAN call.
+	scanBatch = 500
+
+	// Pause between two SCAN calls so redis is not hammered.
+	scanInterval = 50 * time.Millisecond
+
+	// Stop after this many keys sampled.
+	maxSampled = 200000
+
+	// How many rows each top list shows.
+	topN = 10
+)
+
+// Source of notifications from this tab.
+const notifySource = "keystats"
+
+// Facts of one sampled key.
+type keyInfo struct {
+	Key    string
+	Type   string
+	Memory int64
+	TTL    time.Duration
+	Length int64
+}
+
+// Use to deliver one scan batch, Scan tells batch from cancelled scan.
+type scanStepMsg struct {
+	Scan   int
+	Cursor uint64
+	Keys   []keyInfo
+	Err    error
+}
+
+// Count and memory of a group of keys.
+type aggregate struct {
+	Name   string
+	Count  int
+	Memory int64
+}
+
+type keyMap struct {
+	Start key.Binding
+	Stop  key.Binding
+}
+
+func newKeyMap() keyMap {
+	return keyMap{
+		Start: keymap.New("keystats.start", "start scan", "s"),
+		Stop:  keymap.New("keystats.stop", "stop scan", "x"),
+	}
+}
+
+type Model struct {
+	bindings keyMap
+	rdb      *redis.Client
+	sep      string
+
+	// Id of current scan, bumped to cancel.
+	scan    int
+	running bool
+	sampled int
+	err     error
+	started time.Time
+	elapsed time.Duration
+
+	byType   map[string]*aggregate
+	byPrefix map[string]*aggregate
+	biggest  []keyInfo
+	streams  []keyInfo
+	noTTL    int
+}
+
+func New(separator string) Model {
+	return Model{bindings: newKeyMap(), sep: separator}
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case msgs.RedisStateMsg:
+		m.rdb = msg.Client
+		return m, nil
+
+	case scanStepMsg:
+		if msg.Scan != m.scan || !m.running {
+			return m, nil
+		}
+		if msg.Err != nil {
+			m.err = msg.Err
+			m.running = false
+			return m, notify.Err(notifySource, fmt.Errorf("scan keys: %w", msg.Err))
+		}
+
+		for i := range msg.Keys {
+			m.add(&msg.Keys[i])
+		}
+		m.elapsed = time.Since(m.started)
+
+		if msg.Cursor == 0 || m.sampled >= maxSampled {
+			m.running = false
+			return m, nil
+		}
+		return m, scanStep(m.rdb, m.scan, msg.Cursor, scanInterval)
+
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.bindings.Start):
+			return m.start()
+		case key.Matches(msg, m.bindings.Stop):
+			return m.stop(), nil
+		}
+		return m, nil
+
+	case startScanMsg:
+		return m.start()
+
+	case stopScanMsg:
+		return m.stop(), nil
+	}
+
+	return m, nil
+}
+
+// Use to start or stop scan from palette.
+type (
+	startScanMsg struct{}
+	stopScanMsg  struct{}
+)
+
+func (m Model) start() (tea.Model, tea.Cmd) {
+	if m.rdb == nil {
+		return m, nil
+	}
+	m = m.reset()
+	m.running = true
+	return m, scanStep(m.rdb, m.scan, 0, 0)
+}
+
+// Batch in flight is dropped because scan id changes.
+func (m Model) stop() Model {
+	m.scan++
+	m.running = false
+	return m
+}
+
+// Clear result for a new scan.
+func (m Model) reset() Model {
+	m.scan++
+	m.sampled = 0
+	m.err = nil
+	m.started = time.Now()
+	m.elapsed = 0
+	m.byType = make(map[string]*aggregate)
+	m.byPrefix = make(map[string]*aggregate)
+	m.biggest = nil
+	m.streams = nil
+	m.noTTL = 0
+	return m
+}
+
+func (m *Model) add(k *keyInfo) {
+	// Key deleted between SCAN and TYPE.
+	if k.Type == "none" {
+		return
+	}
+	m.sampled++
+
+	addTo(m.byType, k.Type, k.Memory)
+	prefix, _, found := strings.Cut(k.Key, m.sep)
+	if !found {
+		prefix = "(no namespace)"
+	} else {
+		prefix += m.sep
+	}
+	addTo(m.byPrefix, prefix, k.Memory)
+
+	// TTL is -1 for key without expire, -2 for key deleted after TYPE.
+	if k.TTL == -1 {
+		m.noTTL++
+	}
+
+	m.biggest = insertTop(m.biggest, *k, func(a, b *keyInfo) bool { return a.Memory > b.Memory })
+	if k.Type == "stream" {
+		m.streams = insertTop(m.streams, *k, func(a, b *keyInfo) bool { return a.Length > b.Length })
+	}
+}
+
+func addTo(groups map[string]*aggregate, name string, memory int64) {
+	g, ok := groups[name]
+	if !ok {
+		g = &aggregate{Name: name}
+		groups[name] = g
+	}
+	g.Count++
+	g.Memory += memory
+}
+
+// Insert key into list kept sorted by less, list never grows over topN.
+func insertTop(list []keyInfo, k keyInfo, less func(a, b *keyInfo) bool) []keyInfo {
+	pos := sort.Search(len(list), func(i int) bool { return less(&k, &list[i]) })
+	if pos >= topN {
+		return list
+	}
+	list = append(list, keyInfo{})
+	copy(list[pos+1:], list[pos:])
+	list[pos] = k
+	if len(list) > topN {
+		list = list[:topN]
+	}
+	return list
+}
+
+// Command run one SCAN and inspect keys it returns.
+func scanStep(rdb *redis.Client, scan int, cursor uint64, delay time.Duration) tea.Cmd {
+	return func() tea.Msg {
+		time.Sleep(delay)
+		ctx := context.Background()
+		result := scanStepMsg{Scan: scan}
+
+		var keys []string
+		keys, result.Cursor, result.Err = rdb.Scan(ctx, cursor, "", scanBatch).Result()
+		if result.Err != nil || len(keys) == 0 {
+			return result
+		}
+
+		// Type decides how to measure length, so read it first.
+		pipe := rdb.Pipeline()
+		types := make([]*redis.StatusCmd, len(keys))
+		for i, key := range keys {
+			types[i] = pipe.Type(ctx, key)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			result.Err = err
+			return result
+		}
+
+		pipe = rdb.Pipeline()
+		memory := make([]*redis.IntCmd, len(keys))
+		ttl := make([]*redis.DurationCmd, len(keys))
+		length := make([]*redis.IntCmd, len(keys))
+		for i, key := range keys {
+			memory[i] = pipe.MemoryUsage(ctx, key)
+			ttl[i] = pipe.TTL(ctx, key)
+			switch types[i].Val() {
+			case "string":
+				length[i] = pipe.StrLen(ctx, key)
+			case "list":
+				length[i] = pipe.LLen(ctx, key)
+			case "hash":
+				length[i] = pipe.HLen(ctx, key)
+			case "set":
+				length[i] = pipe.SCard(ctx, key)
+			case "zset":
+				length[i] = pipe.ZCard(ctx, key)
+			case "stream":
+				length[i] = pipe.XLen(ctx, key)
+			}
+		}
+		// Key may expire between calls, so errors of single command are ignored.
+		pipe.Exec(ctx)
+
+		for i, key := range keys {
+			info := keyInfo{
+				Key:    key,
+				Type:   types[i].Val(),
+				Memory: memory[i].Val(),
+				TTL:    ttl[i].Val(),
+			}
+			if length[i] != nil {
+				info.Length = length[i].Val()
+			}
+			result.Keys = append(result.Keys, info)
+		}
+		return result
+	}
+}
+
+func (m Model) View() string {
+	if m.rdb == nil {
+		return "Redis disconnected."
+	}
+	if m.byType == nil {
+		return "Sample keyspace with SCAN, " + keymap.HelpText(m.bindings.Start, m.bindings.Stop) + "."
+	}
+
+	var builder strings.Builder
+	if m.err != nil {
+		builder.WriteString("Scan error " + m.err.Error() + "\n")
+	}
+
+	left := lipgloss.JoinVertical(lipgloss.Left,
+		aggregateTable("BY TYPE", m.byType),
+		"",
+		aggregateTable("BY NAMESPACE", m.byPrefix),
+	)
+	right := lipgloss.JoinVertical(lipgloss.Left,
+		keyTable("BIGGEST KEYS", "MEMORY", m.biggest, func(k *keyInfo) string { return style.Bytes(k.Memory) }),
+		"",
+		keyTable("LONGEST STREAMS", "ENTRIES", m.streams, func(k *keyInfo) string { return fmt.Sprintf("%d", k.Length) }),
+		"",
+		fmt.Sprintf("%d of %d sampled keys have no TTL", m.noTTL, m.sampled),
+	)
+
+	builder.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, left, "  ", right))
+	return builder.String()
+}
+
+func (m Model) Actions() []tab.Action {
+	return []tab.Action{
+		{Title: "Start keyspace scan", Msg: startScanMsg{}},
+		{Title: "Cancel keyspace scan", Msg: stopScanMsg{}},
+	}
+}
+
+func (m Model) Help() help.KeyMap {
+	k := m.bindings
+	return keymap.Help{Short: []key.Binding{k.Start, k.Stop}, Full: [][]key.Binding{{k.Start, k.Stop}}}
+}
+
+func (m Model) StatusBarView() string {
+	state := "done"
+	if m.running {
+		state = "scanning"
+	}
+	return statusStyle.Render(fmt.Sprintf("%d keys %s %s", m.sampled, state, m.elapsed.Round(time.Second)))
+}
+
+func aggregateTable(title string, groups map[string]*aggregate) string {
+	list := make([]*aggregate, 0, len(groups))
+	for _, g := range groups {
+		list = append(list, g)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Memory != list[j].Memory {
+			return list[i].Memory > list[j].Memory
+		}
+		return list[i].Name < list[j].Name
+	})
+
+	rows := []string{sectionStyle.Render(nameStyle.Render(title) + countStyle.Render("KEYS") + memoryStyle.Render("MEMORY"))}
+	for i, g := range list {
+		if i >= topN {
+			rows = append(rows, fmt.Sprintf("and %d more", len(list)-topN))
+			break
+		}
+		rows = append(rows, nameStyle.Render(g.Name)+countStyle.Render(fmt.Sprintf("%d", g.Count))+memoryStyle.Render(style.Bytes(g.Memory)))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+}
+
+func keyTable(title, column string, keys []keyInfo, value func(*keyInfo) string) string {
+	rows := []string{sectionStyle.Render(nameStyle.Render(title) + memoryStyle.Render(column))}
+	for i := range keys {
+		rows = append(rows, nameStyle.Render(keys[i].Key)+memoryStyle.Render(value(&keys[i])))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+}
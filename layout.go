@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"gw/dispatcher/debugger/tab"
+	"slices"
+)
+
+// Size of a new pane, panes grow and shrink by one.
+const (
+	paneWeight    = 4
+	minPaneWeight = 1
+)
+
+// Rows of pane title above pane content.
+const paneTitleHeight = 1
+
+// Area of a pane in main box.
+type rect struct {
+	x, y          int
+	width, height int
+}
+
+func (r rect) contains(x, y int) bool {
+	return x >= r.x && x < r.x+r.width && y >= r.y && y < r.y+r.height
+}
+
+// Tabs shown together in main box, each one in a pane.
+type layout struct {
+	// Tab index of each pane.
+	panes []int
+	// Share of main box each pane takes.
+	weights []int
+	// Panes top to bottom instead of side by side.
+	stacked bool
+}
+
+// Layout with a pane for every tab named, names not shown are an error.
+func newLayout(tabs []tab.Tab, names []string, stacked bool) (layout, error) {
+	l := layout{stacked: stacked}
+	for _, name := range names {
+		i := slices.IndexFunc(tabs, func(t tab.Tab) bool { return t.Name == name })
+		if i < 0 {
+			return l, fmt.Errorf("split tab %q is not shown", name)
+		}
+		if l.pane(i) < 0 {
+			l.panes = append(l.panes, i)
+			l.weights = append(l.weights, paneWeight)
+		}
+	}
+	if !l.split() {
+		l.panes, l.weights = nil, nil
+	}
+	return l, nil
+}
+
+// Split mode is on with more than one pane, otherwise only focused tab is shown.
+func (l layout) split() bool {
+	return len(l.panes) > 1
+}
+
+// Pane showing tab, -1 if tab is not in a pane.
+func (l layout) pane(i int) int {
+	return slices.Index(l.panes, i)
+}
+
+// Add pane of tab after pane of focused tab.
+func (l layout) add(focused int, i int) layout {
+	if !l.split() {
+		l.panes, l.weights = []int{focused}, []int{paneWeight}
+	}
+	at := l.pane(focused) + 1
+	l.panes = slices.Insert(slices.Clone(l.panes), at, i)
+	l.weights = slices.Insert(slices.Clone(l.weights), at, paneWeight)
+	return l
+}
+
+// Remove pane of tab, split mode ends when one pane is left.
+func (l layout) remove(i int) layout {
+	p := l.pane(i)
+	if p < 0 {
+		return l
+	}
+	l.panes = slices.Delete(slices.Clone(l.panes), p, p+1)
+	l.weights = slices.Delete(slices.Clone(l.weights), p, p+1)
+	if len(l.panes) < 2 {
+		l.panes, l.weights = nil, nil
+	}
+	return l
+}
+
+// Show tab in pane of old tab.
+func (l layout) replace(old int, i int) layout {
+	if p := l.pane(old); p >= 0 {
+		l.panes = slices.Clone(l.panes)
+		l.panes[p] = i
+	}
+	return l
+}
+
+// Grow pane of tab by delta, shrink with negative delta.
+func (l layout) grow(i int, delta int) layout {
+	if p := l.pane(i); p >= 0 {
+		l.weights = slices.Clone(l.weights)
+		l.weights[p] = max(minPaneWeight, l.weights[p]+delta)
+	}
+	return l
+}
+
+// Area of every pane in a main box of width and height.
+func (l layout) rects(width int, height int) []rect {
+	total := width
+	if l.stacked {
+		total = height
+	}
+	sum := 0
+	for _, w := range l.weights {
+		sum += w
+	}
+
+	result := make([]rect, len(l.panes))
+	pos := 0
+	for i, w := range l.weights {
+		size := total * w / sum
+		// Last pane takes what's left from rounding.
+		if i == len(l.weights)-1 {
+			size = total - pos
+		}
+		if l.stacked {
+			result[i] = rect{x: 0, y: pos, width: width, height: size}
+		} else {
+			result[i] = rect{x: pos, y: 0, width: size, height: height}
+		}
+		pos += size
+	}
+	return result
+}
+
+// Area of pane content, without pane title and the border between side by side panes.
+func (l layout) content(pane int, r rect) rect {
+	r.y += paneTitleHeight
+	r.height = max(0, r.height-paneTitleHeight)
+	if !l.stacked && pane > 0 {
+		r.x++
+		r.width = max(0, r.width-1)
+	}
+	return r
+}
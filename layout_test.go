@@ -0,0 +1,128 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLayoutRects(t *testing.T) {
+	tests := []struct {
+		name   string
+		layout layout
+		width  int
+		height int
+		want   []rect
+	}{
+		{
+			name:   "even side by side",
+			layout: layout{panes: []int{0, 1}, weights: []int{4, 4}},
+			width:  80,
+			height: 20,
+			want:   []rect{{0, 0, 40, 20}, {40, 0, 40, 20}},
+		},
+		{
+			name:   "last pane takes rounding",
+			layout: layout{panes: []int{0, 1, 2}, weights: []int{4, 4, 4}},
+			width:  80,
+			height: 20,
+			want:   []rect{{0, 0, 26, 20}, {26, 0, 26, 20}, {52, 0, 28, 20}},
+		},
+		{
+			name:   "weighted",
+			layout: layout{panes: []int{0, 1}, weights: []int{6, 2}},
+			width:  80,
+			height: 20,
+			want:   []rect{{0, 0, 60, 20}, {60, 0, 20, 20}},
+		},
+		{
+			name:   "stacked",
+			layout: layout{panes: []int{0, 1}, weights: []int{4, 4}, stacked: true},
+			width:  80,
+			height: 21,
+			want:   []rect{{0, 0, 80, 10}, {0, 10, 80, 11}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.layout.rects(tt.width, tt.height); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLayoutContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		stacked bool
+		pane    int
+		r       rect
+		want    rect
+	}{
+		{"first pane loses title", false, 0, rect{0, 0, 40, 20}, rect{0, 1, 40, 19}},
+		{"later pane loses border", false, 1, rect{40, 0, 40, 20}, rect{41, 1, 39, 19}},
+		{"stacked pane has no border", true, 1, rect{0, 10, 80, 10}, rect{0, 11, 80, 9}},
+		{"too small", false, 1, rect{40, 0, 0, 0}, rect{41, 1, 0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := layout{stacked: tt.stacked}
+			if got := l.content(tt.pane, tt.r); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLayoutAdd(t *testing.T) {
+	tests := []struct {
+		name        string
+		layout      layout
+		focused     int
+		tab         int
+		wantPanes   []int
+		wantWeights []int
+	}{
+		{"start split", layout{}, 2, 5, []int{2, 5}, []int{4, 4}},
+		{"after focused", layout{panes: []int{0, 1}, weights: []int{4, 2}}, 0, 3, []int{0, 3, 1}, []int{4, 4, 2}},
+		{"after last", layout{panes: []int{0, 1}, weights: []int{4, 4}}, 1, 3, []int{0, 1, 3}, []int{4, 4, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.layout.add(tt.focused, tt.tab)
+			if !reflect.DeepEqual(got.panes, tt.wantPanes) || !reflect.DeepEqual(got.weights, tt.wantWeights) {
+				t.Errorf("got panes %v weights %v, want %v %v", got.panes, got.weights, tt.wantPanes, tt.wantWeights)
+			}
+		})
+	}
+}
+
+func TestLayoutAddKeepsOriginal(t *testing.T) {
+	l := layout{panes: []int{0, 1}, weights: []int{4, 4}}
+	l.add(0, 2)
+	if !reflect.DeepEqual(l.panes, []int{0, 1}) || !reflect.DeepEqual(l.weights, []int{4, 4}) {
+		t.Errorf("original changed to panes %v weights %v", l.panes, l.weights)
+	}
+}
+
+func TestLayoutRemove(t *testing.T) {
+	tests := []struct {
+		name        string
+		layout      layout
+		tab         int
+		wantPanes   []int
+		wantWeights []int
+	}{
+		{"middle", layout{panes: []int{0, 1, 2}, weights: []int{1, 2, 3}}, 1, []int{0, 2}, []int{1, 3}},
+		{"not in pane", layout{panes: []int{0, 1}, weights: []int{4, 4}}, 5, []int{0, 1}, []int{4, 4}},
+		{"last two ends split", layout{panes: []int{0, 1}, weights: []int{4, 4}}, 0, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.layout.remove(tt.tab)
+			if !reflect.DeepEqual(got.panes, tt.wantPanes) || !reflect.DeepEqual(got.weights, tt.wantWeights) {
+				t.Errorf("got panes %v weights %v, want %v %v", got.panes, got.weights, tt.wantPanes, tt.wantWeights)
+			}
+		})
+	}
+}
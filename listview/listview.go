@@ -0,0 +1,138 @@
+package listview
+
+import (
+	"fmt"
+	"gw/dispatcher/debugger/keymap"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type keyMap struct {
+	Up       key.Binding
+	Down     key.Binding
+	PageUp   key.Binding
+	PageDown key.Binding
+	Home     key.Binding
+	End      key.Binding
+}
+
+// Selection cursor and viewport of a list, the component renders rows itself.
+type Model struct {
+	bindings keyMap
+
+	cursor int
+	// First visible row.
+	offset int
+	count  int
+	height int
+}
+
+func New() Model {
+	return Model{
+		bindings: keyMap{
+			Up:       keymap.New("up", "move up", "up"),
+			Down:     keymap.New("down", "move down", "down"),
+			PageUp:   keymap.New("page_up", "page up", "pgup"),
+			PageDown: keymap.New("page_down", "page down", "pgdown"),
+			Home:     keymap.New("home", "first", "home"),
+			End:      keymap.New("end", "last", "end"),
+		},
+		height: 1,
+	}
+}
+
+func (m Model) Cursor() int {
+	return m.cursor
+}
+
+// Rows in view, from start to end exclusive.
+func (m Model) Range() (int, int) {
+	return m.offset, min(m.offset+m.height, m.count)
+}
+
+// Set number of rows, cursor stays in list.
+func (m Model) SetCount(n int) Model {
+	m.count = n
+	return m.SetCursor(m.cursor)
+}
+
+// Set rows the view can show.
+func (m Model) SetHeight(h int) Model {
+	m.height = max(1, h)
+	return m.SetCursor(m.cursor)
+}
+
+// Move cursor to row, offset follows so the cursor stays in view.
+func (m Model) SetCursor(i int) Model {
+	m.cursor = max(0, min(i, m.count-1))
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+m.height {
+		m.offset = m.cursor - m.height + 1
+	}
+	// Don't leave empty rows at bottom when list shrinks.
+	m.offset = max(0, min(m.offset, m.count-m.height))
+	return m
+}
+
+// Handle navigation keys and mouse wheel, ok is false if msg is not for list.
+func (m Model) Update(msg tea.Msg) (Model, bool) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.bindings.Up):
+			return m.SetCursor(m.cursor - 1), true
+		case key.Matches(msg, m.bindings.Down):
+			return m.SetCursor(m.cursor + 1), true
+		case key.Matches(msg, m.bindings.PageUp):
+			m.offset = max(0, m.offset-m.height)
+			return m.SetCursor(m.cursor - m.height), true
+		case key.Matches(msg, m.bindings.PageDown):
+			m.offset = max(0, min(m.offset+m.height, m.count-m.height))
+			return m.SetCursor(m.cursor + m.height), true
+		case key.Matches(msg, m.bindings.Home):
+			return m.SetCursor(0), true
+		case key.Matches(msg, m.bindings.End):
+			return m.SetCursor(m.count - 1), true
+		}
+	case tea.MouseMsg:
+		switch msg.Button {
+		case tea.MouseButtonWheelUp:
+			return m.SetCursor(m.cursor - 1), true
+		case tea.MouseButtonWheelDown:
+			return m.SetCursor(m.cursor + 1), true
+		}
+	}
+	return m, false
+}
+
+// Select row at line y of view, ok is false if there is no row.
+func (m Model) Click(y int) (Model, bool) {
+	if y < 0 || y >= m.height || m.offset+y >= m.count {
+		return m, false
+	}
+	return m.SetCursor(m.offset + y), true
+}
+
+// Position like "21-40/300 ▲▼", arrows tell there are rows out of view.
+func (m Model) Indicator() string {
+	if m.count == 0 {
+		return "0/0"
+	}
+	start, end := m.Range()
+	text := fmt.Sprintf("%d-%d/%d", start+1, end, m.count)
+	if start > 0 {
+		text += " ▲"
+	}
+	if end < m.count {
+		text += " ▼"
+	}
+	return text
+}
+
+// Bindings for help.
+func (m Model) Bindings() []key.Binding {
+	return []key.Binding{m.bindings.Up, m.bindings.Down, m.bindings.PageUp, m.bindings.PageDown, m.bindings.Home, m.bindings.End}
+}
@@ -0,0 +1,124 @@
+package listview
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSetCursor(t *testing.T) {
+	tests := []struct {
+		name       string
+		count      int
+		height     int
+		from       int
+		to         int
+		wantCursor int
+		wantStart  int
+		wantEnd    int
+	}{
+		{"in view", 10, 5, 0, 3, 3, 0, 5},
+		{"below view scrolls down", 10, 5, 0, 7, 7, 3, 8},
+		{"above view scrolls up", 10, 5, 9, 2, 2, 2, 7},
+		{"past end stays on last", 10, 5, 0, 20, 9, 5, 10},
+		{"before start stays on first", 10, 5, 9, -3, 0, 0, 5},
+		{"short list", 3, 5, 0, 2, 2, 0, 3},
+		{"empty list", 0, 5, 0, 2, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New().SetCount(tt.count).SetHeight(tt.height).SetCursor(tt.from).SetCursor(tt.to)
+			start, end := m.Range()
+			if m.Cursor() != tt.wantCursor || start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("cursor %d range %d-%d, want %d range %d-%d",
+					m.Cursor(), start, end, tt.wantCursor, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestSetCountShrink(t *testing.T) {
+	m := New().SetCount(20).SetHeight(5).SetCursor(19)
+	m = m.SetCount(7)
+	start, end := m.Range()
+	if m.Cursor() != 6 || start != 2 || end != 7 {
+		t.Errorf("cursor %d range %d-%d, want 6 range 2-7", m.Cursor(), start, end)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	key := func(k tea.KeyType) tea.Msg { return tea.KeyMsg{Type: k} }
+	tests := []struct {
+		name       string
+		cursor     int
+		msg        tea.Msg
+		wantCursor int
+		wantOK     bool
+	}{
+		{"down", 0, key(tea.KeyDown), 1, true},
+		{"up at top", 0, key(tea.KeyUp), 0, true},
+		{"down at bottom", 9, key(tea.KeyDown), 9, true},
+		{"page down", 0, key(tea.KeyPgDown), 4, true},
+		{"page up", 9, key(tea.KeyPgUp), 5, true},
+		{"home", 6, key(tea.KeyHome), 0, true},
+		{"end", 2, key(tea.KeyEnd), 9, true},
+		{"wheel down", 3, tea.MouseMsg{Button: tea.MouseButtonWheelDown}, 4, true},
+		{"other key", 3, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")}, 3, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New().SetCount(10).SetHeight(4).SetCursor(tt.cursor)
+			m, ok := m.Update(tt.msg)
+			if m.Cursor() != tt.wantCursor || ok != tt.wantOK {
+				t.Errorf("cursor %d ok %v, want %d ok %v", m.Cursor(), ok, tt.wantCursor, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestClick(t *testing.T) {
+	tests := []struct {
+		name       string
+		y          int
+		wantCursor int
+		wantOK     bool
+	}{
+		{"first row in view", 0, 3, true},
+		{"last row in view", 3, 6, true},
+		{"below view", 4, 6, false},
+		{"above view", -1, 6, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Rows 3-6 in view, cursor on 6.
+			m := New().SetCount(10).SetHeight(4).SetCursor(6)
+			m, ok := m.Click(tt.y)
+			if m.Cursor() != tt.wantCursor || ok != tt.wantOK {
+				t.Errorf("cursor %d ok %v, want %d ok %v", m.Cursor(), ok, tt.wantCursor, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestIndicator(t *testing.T) {
+	tests := []struct {
+		name   string
+		count  int
+		cursor int
+		want   string
+	}{
+		{"empty", 0, 0, "0/0"},
+		{"all in view", 3, 0, "1-3/3"},
+		{"more below", 10, 0, "1-4/10 ▼"},
+		{"more both ways", 10, 5, "3-6/10 ▲ ▼"},
+		{"more above", 10, 9, "7-10/10 ▲"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New().SetCount(tt.count).SetHeight(4).SetCursor(tt.cursor)
+			if got := m.Indicator(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
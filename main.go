@@ -3,6 +3,15 @@ package main
 import (
 	"flag"
 	"fmt"
+	"gw/dispatcher/debugger/audit"
+	"gw/dispatcher/debugger/config"
+	"gw/dispatcher/debugger/keylist"
+	"gw/dispatcher/debugger/keymap"
+	"gw/dispatcher/debugger/stuck"
+	"gw/dispatcher/debugger/theme"
+	"os"
+	"path/filepath"
+	"syscall"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -12,22 +21,182 @@ func main() {
 	var port int
 	var password string
 	var db int
+	var configPath string
+	var profile string
+	var readonly bool
+	var auditPath string
+	var keySeparator string
+	var noMouse bool
+	var themeName string
+	var colorMode string
+	stuckConfig := stuck.DefaultConfig()
 
 	flag.StringVar(&addr, "h", "127.0.0.1", "redis host")
 	flag.IntVar(&port, "p", 6379, "redis port")
 	flag.StringVar(&password, "pwd", "", "password")
 	flag.IntVar(&db, "db", 0, "redis db")
+	flag.StringVar(&configPath, "config", config.DefaultPath(), "config file")
+	flag.StringVar(&profile, "profile", "", "connect with profile in config file")
+	flag.BoolVar(&readonly, "readonly", false, "reject every mutating command, default on for production profile")
+	flag.StringVar(&auditPath, "audit", filepath.Join(filepath.Dir(config.DefaultPath()), audit.FileName), "audit log of mutating commands")
+	flag.StringVar(&keySeparator, "key-sep", keylist.DefaultSeparator, "separator of key namespace in key tree")
+	flag.BoolVar(&noMouse, "no-mouse", false, "disable mouse, keeps text selection of terminal")
+	flag.StringVar(&themeName, "theme", "", "theme name, built-in dark, light, high-contrast or file in themes dir of config dir")
+	flag.StringVar(&colorMode, "color", "", "color mode, auto, truecolor, 256, 16 or none for monochrome, auto respects NO_COLOR")
+	flag.DurationVar(&stuckConfig.Idle, "stuck-idle", stuckConfig.Idle, "pending entry idle longer than this is stuck")
+	flag.Int64Var(&stuckConfig.MaxDeliveries, "stuck-deliveries", stuckConfig.MaxDeliveries, "pending entry delivered more times than this is stuck")
 	flag.Parse()
 
-	app := NewApp()
-	app.rdbConfig = redisConfig{
-		host:     addr,
-		port:     port,
-		password: password,
-		db:       db,
+	if keySeparator == "" {
+		fmt.Println("-key-sep must not be empty")
+		os.Exit(1)
 	}
 
-	if _, err := tea.NewProgram(app, tea.WithAltScreen()).Run(); err != nil {
+	cfg, err := config.Load(configPath)
+	if err != nil {
 		fmt.Println(err)
+		os.Exit(1)
 	}
+
+	rdbConfig := newRedisConfig()
+	if profile != "" {
+		p, err := cfg.Profile(profile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		rdbConfig = redisConfig{
+			profile:  profile,
+			host:     p.Host,
+			port:     p.Port,
+			password: p.Password,
+			db:       p.DB,
+			readonly: p.IsReadOnly(),
+		}
+		if rdbConfig.host == "" {
+			rdbConfig.host = addr
+		}
+		if rdbConfig.port == 0 {
+			rdbConfig.port = port
+		}
+	}
+
+	// Flags given on command line override profile.
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "h":
+			rdbConfig.host = addr
+		case "p":
+			rdbConfig.port = port
+		case "pwd":
+			rdbConfig.password = password
+		case "db":
+			rdbConfig.db = db
+		case "readonly":
+			rdbConfig.readonly = readonly
+		}
+	})
+
+	auditLog := audit.NewLogger(auditPath, rdbConfig.profile, rdbConfig.addr())
+
+	// Console history is kept per profile, or per address without profile.
+	historyName := rdbConfig.profile
+	if historyName == "" {
+		historyName = fmt.Sprintf("%s_%d_%d", rdbConfig.host, rdbConfig.port, rdbConfig.db)
+	}
+	historyPath := filepath.Join(filepath.Dir(config.DefaultPath()), "history", historyName)
+
+	// Themes dir holds "<name>.yaml" files, flag overrides theme in config.
+	if err := theme.Load(filepath.Join(filepath.Dir(config.DefaultPath()), "themes")); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if themeName == "" {
+		themeName = cfg.Theme
+	}
+	if themeName == "" {
+		themeName = theme.Default
+	}
+	if err := theme.Set(themeName); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if colorMode == "" {
+		colorMode = cfg.Color
+	}
+	if colorMode == "" {
+		colorMode = "auto"
+	}
+	if err := theme.SetColorMode(colorMode); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	keymap.SetOverrides(cfg.Keys)
+	tabs, err := builtinTabs(tabDeps{
+		config:       cfg,
+		stuck:        stuckConfig,
+		auditLog:     auditLog,
+		keySeparator: keySeparator,
+		historyPath:  historyPath,
+	}).Build(cfg.Tabs, cfg.DisabledTabs)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	layout, err := newLayout(tabs, cfg.Split, cfg.SplitStacked)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	app := NewApp(tabs, auditLog)
+	app.layout = layout
+	if layout.split() {
+		app.csr = layout.panes[0]
+	}
+	app.rdbConfig = rdbConfig
+	app.profiles = cfg.Profiles
+
+	options := []tea.ProgramOption{tea.WithAltScreen()}
+	if !noMouse {
+		options = append(options, tea.WithMouseCellMotion())
+	}
+	final, err := tea.NewProgram(app, options...).Run()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// Profile picked in palette, start over connected to it.
+	if a, ok := final.(App); ok && a.switchProfile != "" {
+		if err := restartWithProfile(a.switchProfile); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+}
+
+// Replace this process with the debugger connected to profile, flags not
+// about connection are kept. -readonly given on command line is kept too, so
+// switching profile never turns a read-only session writable, profile default
+// decides only if it was not given. Returns only if exec fails.
+func restartWithProfile(profile string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	args := []string{os.Args[0]}
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "h", "p", "pwd", "db", "profile":
+		default:
+			args = append(args, "-"+f.Name+"="+f.Value.String())
+		}
+	})
+	args = append(args, "-profile="+profile)
+
+	return syscall.Exec(exe, args, os.Environ())
 }
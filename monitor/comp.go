@@ -0,0 +1,471 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"gw/dispatcher/debugger/keymap"
+	"gw/dispatcher/debugger/msgs"
+	"gw/dispatcher/debugger/notify"
+	"gw/dispatcher/debugger/style"
+	"gw/dispatcher/debugger/tab"
+	"gw/dispatcher/debugger/theme"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	timeStyle   = style.W().M
+	addrStyle   = style.W().L
+	cmdStyle    = style.W().S.Bold(true)
+	statusStyle = style.W().L.Padding(0, 1)
+)
+
+// Rebuilt on theme change.
+var warningText lipgloss.Style
+
+func init() {
+	theme.OnChange(func(t theme.Theme) {
+		warningText = t.On(t.Warning).Padding(0, 1)
+	})
+}
+
+const (
+	// Lines kept in log.
+	maxLines = 5000
+
+	// Lines are delivered to UI in batches, once per window.
+	batchWindow = 100 * time.Millisecond
+
+	// Lines accepted per batch window, the rest are dropped.
+	maxBatch = 200
+
+	// Filter and search lines above log.
+	chromeHeight = 2
+)
+
+// Source of notifications from this tab.
+const notifySource = "monitor"
+
+const warning = `MONITOR streams every command the server executes back to this client.
+It can cut Redis throughput by half or more on a busy server, so keep it short on production.
+A dedicated connection is used and closed on stop.`
+
+type stage int
+
+const (
+	stageIdle stage = iota
+	stageWarning
+	stageRunning
+)
+
+// MONITOR running on a dedicated client.
+type session struct {
+	id     int
+	client *redis.Client
+	cmd    *redis.MonitorCmd
+	ch     chan string
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (s *session) stop() {
+	s.cmd.Stop()
+	s.cancel()
+	s.client.Close()
+}
+
+type monitorStartedMsg struct {
+	ID      int
+	Session *session
+	Err     error
+}
+
+type monitorLinesMsg struct {
+	ID      int
+	Lines   []string
+	Dropped int
+}
+
+type keyMap struct {
+	keymap.Common
+	Start  key.Binding
+	Stop   key.Binding
+	Pause  key.Binding
+	Clear  key.Binding
+	Filter key.Binding
+	Search key.Binding
+	Done   key.Binding
+}
+
+func newKeyMap() keyMap {
+	return keyMap{
+		Common: keymap.NewCommon(),
+		Start:  keymap.New("monitor.start", "start", "s"),
+		Stop:   keymap.New("monitor.stop", "stop", "x"),
+		Pause:  keymap.New("monitor.pause", "pause", "p"),
+		Clear:  keymap.New("monitor.clear", "clear", "c"),
+		Filter: keymap.New("monitor.filter", "filter", "f"),
+		Search: keymap.New("monitor.search", "search", "/"),
+		Done:   keymap.New("monitor.done", "done", "enter", "esc"),
+	}
+}
+
+type Model struct {
+	bindings keyMap
+
+	rdb     *redis.Client
+	stage   stage
+	session *session
+	nextID  int
+
+	entries []entry
+	dropped int
+	paused  bool
+	// Lines from bottom when paused and scrolled.
+	scroll int
+
+	filter      filter
+	filterInput textinput.Model
+	searchInput textinput.Model
+
+	height int
+	width  int
+}
+
+func New() Model {
+	filterInput := textinput.New()
+	filterInput.Prompt = "filter> "
+	filterInput.Placeholder = "cmd:xadd,xack key:*::stream::gw runner:<name>"
+
+	searchInput := textinput.New()
+	searchInput.Prompt = "search> "
+
+	return Model{bindings: newKeyMap(), filterInput: filterInput, searchInput: searchInput}
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+// Open a dedicated client and start MONITOR on it.
+func startMonitor(rdb *redis.Client, id int) tea.Cmd {
+	return func() tea.Msg {
+		opt := *rdb.Options()
+		opt.PoolSize = 1
+		opt.MinIdleConns = 0
+
+		ctx, cancel := context.WithCancel(context.Background())
+		s := &session{
+			id:     id,
+			client: redis.NewClient(&opt),
+			ch:     make(chan string, maxBatch*4),
+			ctx:    ctx,
+			cancel: cancel,
+		}
+		s.cmd = s.client.Monitor(ctx, s.ch)
+		if err := s.cmd.Err(); err != nil {
+			s.stop()
+			return monitorStartedMsg{ID: id, Err: err}
+		}
+		s.cmd.Start()
+		return monitorStartedMsg{ID: id, Session: s}
+	}
+}
+
+// Command wait for lines, then collect what arrives in one batch window.
+func waitLines(s *session) tea.Cmd {
+	return func() tea.Msg {
+		result := monitorLinesMsg{ID: s.id}
+
+		select {
+		case <-s.ctx.Done():
+			return nil
+		case line := <-s.ch:
+			result.Lines = append(result.Lines, line)
+		}
+
+		deadline := time.After(batchWindow)
+		for {
+			select {
+			case <-s.ctx.Done():
+				return nil
+			case <-deadline:
+				return result
+			case line := <-s.ch:
+				if len(result.Lines) < maxBatch {
+					result.Lines = append(result.Lines, line)
+				} else {
+					result.Dropped++
+				}
+			}
+		}
+	}
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case msgs.RedisStateMsg:
+		m.rdb = msg.Client
+		return m, nil
+
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+		m.width = msg.Width
+		return m, nil
+
+	case monitorStartedMsg:
+		if msg.Err != nil {
+			if m.stage == stageRunning && msg.ID == m.nextID {
+				m.stage = stageIdle
+			}
+			return m, notify.Err(notifySource, fmt.Errorf("start MONITOR: %w", msg.Err))
+		}
+		if m.stage != stageRunning || msg.ID != m.nextID {
+			msg.Session.stop()
+			return m, nil
+		}
+		m.session = msg.Session
+		return m, waitLines(m.session)
+
+	case monitorLinesMsg:
+		if m.session == nil || msg.ID != m.session.id {
+			return m, nil
+		}
+		m.dropped += msg.Dropped
+		added := 0
+		for _, line := range msg.Lines {
+			e, ok := parseLine(line)
+			if !ok || !m.filter.Match(&e) {
+				continue
+			}
+			// Skip the noise of MONITOR itself.
+			if e.Command() == "monitor" {
+				continue
+			}
+			m.entries = append(m.entries, e)
+			added++
+		}
+		// Keep paused view where it is.
+		if m.paused {
+			m.scroll += added
+		}
+		if len(m.entries) > maxLines {
+			m.entries = m.entries[len(m.entries)-maxLines:]
+		}
+		return m, waitLines(m.session)
+
+	case actionMsg:
+		if m.stage == stageWarning {
+			return m, nil
+		}
+		return m.run(msg), nil
+
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+
+	return m, nil
+}
+
+func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.filterInput.Focused() {
+		if key.Matches(msg, m.bindings.Done) {
+			m.filterInput.Blur()
+			m.filter = parseFilter(m.filterInput.Value())
+			return m, nil
+		}
+		var c tea.Cmd
+		m.filterInput, c = m.filterInput.Update(msg)
+		return m, c
+	}
+	if m.searchInput.Focused() {
+		if key.Matches(msg, m.bindings.Done) {
+			m.searchInput.Blur()
+			return m, nil
+		}
+		var c tea.Cmd
+		m.searchInput, c = m.searchInput.Update(msg)
+		return m, c
+	}
+
+	if m.stage == stageWarning {
+		switch {
+		case key.Matches(msg, m.bindings.Confirm):
+			m.stage = stageRunning
+			m.nextID++
+			return m, startMonitor(m.rdb, m.nextID)
+		case key.Matches(msg, m.bindings.Cancel):
+			m.stage = stageIdle
+		}
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(msg, m.bindings.Start):
+		return m.run(actionStart), nil
+	case key.Matches(msg, m.bindings.Stop):
+		return m.run(actionStop), nil
+	case key.Matches(msg, m.bindings.Pause):
+		return m.run(actionPause), nil
+	case key.Matches(msg, m.bindings.Clear):
+		return m.run(actionClear), nil
+	case key.Matches(msg, m.bindings.Filter):
+		return m, m.filterInput.Focus()
+	case key.Matches(msg, m.bindings.Search):
+		return m, m.searchInput.Focus()
+	case key.Matches(msg, m.bindings.Up):
+		if m.paused {
+			m.scroll++
+		}
+	case key.Matches(msg, m.bindings.Down):
+		if m.paused && m.scroll > 0 {
+			m.scroll--
+		}
+	}
+	return m, nil
+}
+
+// Use to run action from key or palette.
+type actionMsg int
+
+const (
+	actionStart actionMsg = iota
+	actionStop
+	actionPause
+	actionClear
+)
+
+func (m Model) run(a actionMsg) Model {
+	switch a {
+	case actionStart:
+		// Warning comes first, MONITOR starts after y.
+		if m.stage == stageIdle && m.rdb != nil {
+			m.stage = stageWarning
+		}
+	case actionStop:
+		if m.session != nil {
+			m.session.stop()
+			m.session = nil
+		}
+		m.stage = stageIdle
+	case actionPause:
+		m.paused = !m.paused
+		m.scroll = 0
+	case actionClear:
+		m.entries = nil
+		m.dropped = 0
+		m.scroll = 0
+	}
+	return m
+}
+
+func (m Model) Actions() []tab.Action {
+	return []tab.Action{
+		{Title: "Start MONITOR", Msg: actionStart},
+		{Title: "Stop MONITOR", Msg: actionStop},
+		{Title: "Toggle pause", Msg: actionPause},
+		{Title: "Clear log", Msg: actionClear},
+	}
+}
+
+// Typing filter or search, or answering warning needs every key.
+func (m Model) CapturingInput() bool {
+	return m.filterInput.Focused() || m.searchInput.Focused() || m.stage == stageWarning
+}
+
+// Entries matching search text.
+func (m Model) visible() []entry {
+	search := m.searchInput.Value()
+	if search == "" {
+		return m.entries
+	}
+	result := make([]entry, 0)
+	for _, e := range m.entries {
+		if strings.Contains(e.Raw, search) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+func (m Model) View() string {
+	if m.rdb == nil {
+		return "Redis disconnected."
+	}
+
+	switch m.stage {
+	case stageIdle:
+		if len(m.entries) == 0 {
+			return warning + "\n\n" + keymap.HelpText(m.bindings.Start)
+		}
+	case stageWarning:
+		return warning + "\n\n" + warningText.Render("Start MONITOR now? "+keymap.HelpText(m.bindings.Confirm, m.bindings.Cancel))
+	}
+
+	var builder strings.Builder
+	builder.WriteString(m.filterInput.View() + "\n")
+	builder.WriteString(m.searchInput.View() + "\n")
+
+	entries := m.visible()
+	size := max(1, m.height-chromeHeight)
+	end := len(entries)
+	if m.paused {
+		end = max(0, end-m.scroll)
+	}
+	start := max(0, end-size)
+	row := lipgloss.NewStyle().MaxWidth(m.width)
+	for i := start; i < end; i++ {
+		builder.WriteString(row.Render(entryRow(&entries[i])) + "\n")
+	}
+	return builder.String()
+}
+
+func entryRow(e *entry) string {
+	args := make([]string, len(e.Args)-1)
+	for i, arg := range e.Args[1:] {
+		args[i] = fmt.Sprintf("%q", arg)
+	}
+	return timeStyle.Render(e.Time.Format("15:04:05.000")) +
+		addrStyle.Render(e.Addr) +
+		cmdStyle.Render(strings.ToUpper(e.Args[0])) + " " +
+		strings.Join(args, " ")
+}
+
+func (m Model) Help() help.KeyMap {
+	k := m.bindings
+	switch {
+	case m.filterInput.Focused() || m.searchInput.Focused():
+		return keymap.Help{Short: []key.Binding{k.Done}, Full: [][]key.Binding{{k.Done}}}
+	case m.stage == stageWarning:
+		return keymap.Help{Short: []key.Binding{k.Confirm, k.Cancel}, Full: [][]key.Binding{{k.Confirm, k.Cancel}}}
+	}
+	return keymap.Help{
+		Short: []key.Binding{k.Start, k.Stop, k.Pause, k.Filter, k.Search},
+		Full: [][]key.Binding{
+			{k.Start, k.Stop, k.Pause, k.Clear},
+			{k.Filter, k.Search, k.Up, k.Down},
+		},
+	}
+}
+
+func (m Model) StatusBarView() string {
+	state := "stopped"
+	switch {
+	case m.session != nil && m.paused:
+		state = "paused"
+	case m.session != nil:
+		state = "running"
+	}
+	text := fmt.Sprintf("%s %d lines", state, len(m.entries))
+	if m.dropped != 0 {
+		text += fmt.Sprintf(" %d dropped", m.dropped)
+	}
+	return statusStyle.Render(text)
+}
@@ -0,0 +1,163 @@
+package monitor
+
+import (
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// One command printed by MONITOR.
+type entry struct {
+	Time time.Time
+	DB   string
+	Addr string
+	Args []string
+
+	// Original line, use by search.
+	Raw string
+}
+
+// Command name in lower case.
+func (e *entry) Command() string {
+	if len(e.Args) == 0 {
+		return ""
+	}
+	return strings.ToLower(e.Args[0])
+}
+
+// Parse MONITOR line looks like:
+//
+//	1700000000.123456 [0 127.0.0.1:6379] "XADD" "key" "*" "field" "value"
+func parseLine(line string) (entry, bool) {
+	e := entry{Raw: line}
+
+	ts, rest, ok := strings.Cut(line, " ")
+	if !ok {
+		return e, false
+	}
+	sec, err := strconv.ParseFloat(ts, 64)
+	if err != nil {
+		return e, false
+	}
+	e.Time = time.Unix(0, int64(sec*float64(time.Second)))
+
+	rest = strings.TrimSpace(rest)
+	if strings.HasPrefix(rest, "[") {
+		client, args, ok := strings.Cut(rest[1:], "]")
+		if !ok {
+			return e, false
+		}
+		e.DB, e.Addr, _ = strings.Cut(client, " ")
+		rest = strings.TrimSpace(args)
+	}
+
+	e.Args = splitQuoted(rest)
+	return e, len(e.Args) != 0
+}
+
+// Split space separated quoted args, escape sequences are decoded.
+func splitQuoted(s string) []string {
+	result := make([]string, 0)
+	for {
+		s = strings.TrimLeft(s, " ")
+		if s == "" {
+			return result
+		}
+		if s[0] != '"' {
+			arg, rest, _ := strings.Cut(s, " ")
+			result = append(result, arg)
+			s = rest
+			continue
+		}
+
+		// Find closing quote which is not escaped.
+		end := 1
+		for end < len(s) {
+			if s[end] == '\\' {
+				end += 2
+				continue
+			}
+			if s[end] == '"' {
+				break
+			}
+			end++
+		}
+		end = min(end, len(s)-1)
+
+		arg, err := strconv.Unquote(s[:end+1])
+		if err != nil {
+			arg = s[1:end]
+		}
+		result = append(result, arg)
+		s = s[end+1:]
+	}
+}
+
+// Which commands are kept, empty field matches everything.
+//
+// Filter text is space separated terms:
+//
+//	cmd:xadd,xack  key:*::stream::gw  runner:gpu-01
+type filter struct {
+	Commands map[string]bool
+	Key      string
+	Runner   string
+}
+
+func parseFilter(text string) filter {
+	f := filter{}
+	for _, term := range strings.Fields(text) {
+		k, v, ok := strings.Cut(term, ":")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(k) {
+		case "cmd":
+			f.Commands = make(map[string]bool)
+			for _, c := range strings.Split(v, ",") {
+				f.Commands[strings.ToLower(c)] = true
+			}
+		case "key":
+			f.Key = v
+		case "runner":
+			f.Runner = v
+		}
+	}
+	return f
+}
+
+func (f *filter) Match(e *entry) bool {
+	if f.Commands != nil && !f.Commands[e.Command()] {
+		return false
+	}
+
+	if f.Key != "" {
+		found := false
+		for _, arg := range e.Args[1:] {
+			if ok, _ := path.Match(f.Key, arg); ok {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	// Runner keys all start with "<name>::runner::".
+	if f.Runner != "" {
+		prefix := f.Runner + "::runner::"
+		found := false
+		for _, arg := range e.Args[1:] {
+			if strings.HasPrefix(arg, prefix) || arg == f.Runner {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -1,9 +1,16 @@
 package msgs
 
-import "github.com/redis/go-redis/v9"
+import (
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
 
 type RedisStateMsg struct {
 	Client *redis.Client
+
+	// Client rejects every mutating command.
+	ReadOnly bool
 }
 
 type ReadgroupStatus struct {
@@ -18,3 +25,23 @@ type StreamUpdateMsg struct {
 	InferDown   ReadgroupStatus
 	ProcessDown ReadgroupStatus
 }
+
+// Pending stream entry which has been idle too long or delivered too many times.
+type StuckEntry struct {
+	Stream     string
+	Group      string
+	ID         string
+	Consumer   string
+	Idle       time.Duration
+	RetryCount int64
+}
+
+type StuckUpdateMsg struct {
+	Entries []StuckEntry
+	Err     error
+}
+
+// Keys found by the last query in raw keys tab.
+type KeysListedMsg struct {
+	Keys []string
+}
@@ -0,0 +1,204 @@
+package notify
+
+import (
+	"fmt"
+	"gw/dispatcher/debugger/listview"
+	"gw/dispatcher/debugger/theme"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+const (
+	// How long a toast stays on screen.
+	toastDuration = 5 * time.Second
+
+	// Toasts shown at once, oldest goes first.
+	maxToasts = 3
+
+	// Errors kept in error log.
+	maxErrors = 1000
+
+	toastWidth = 48
+)
+
+var (
+	toastStyle  = lipgloss.NewStyle().Width(toastWidth).Padding(0, 1)
+	timeStyle   = lipgloss.NewStyle().Faint(true)
+	sourceStyle = lipgloss.NewStyle().Bold(true)
+
+	// Toast color by level, rebuilt on theme change.
+	levelStyle map[Level]lipgloss.Style
+)
+
+func init() {
+	theme.OnChange(func(t theme.Theme) {
+		levelStyle = map[Level]lipgloss.Style{
+			Info:    t.On(t.PanelLight),
+			Warning: t.On(t.Warning),
+			Error:   t.On(t.Error),
+		}
+		// Toast without background gets lost in view under it.
+		if theme.Mono() {
+			for l, s := range levelStyle {
+				levelStyle[l] = s.Reverse(true)
+			}
+		}
+	})
+}
+
+// Use to remove toast when its time is up, app passes it back to Update.
+type ExpireMsg struct {
+	id int
+}
+
+type toast struct {
+	id  int
+	msg Msg
+}
+
+// One error in error log, the same error repeated in a row is counted instead of added.
+type entry struct {
+	Msg
+	count int
+}
+
+// Toasts and error log of the app.
+type Model struct {
+	toasts []toast
+	nextID int
+
+	errors []entry
+	unread int
+	list   listview.Model
+}
+
+func New() Model {
+	return Model{list: listview.New()}
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case Msg:
+		return m.push(msg)
+
+	case ExpireMsg:
+		for i := range m.toasts {
+			if m.toasts[i].id == msg.id {
+				m.toasts = append(m.toasts[:i:i], m.toasts[i+1:]...)
+				break
+			}
+		}
+		return m, nil
+	}
+
+	// Keys and wheel scroll error log.
+	m.list, _ = m.list.Update(msg)
+	return m, nil
+}
+
+func (m Model) push(msg Msg) (Model, tea.Cmd) {
+	if msg.Level == Error {
+		last := len(m.errors) - 1
+		if last >= 0 && m.errors[last].Source == msg.Source && m.errors[last].Text == msg.Text {
+			m.errors[last].Time = msg.Time
+			m.errors[last].count++
+		} else {
+			m.errors = append(m.errors, entry{Msg: msg, count: 1})
+			if len(m.errors) > maxErrors {
+				m.errors = m.errors[len(m.errors)-maxErrors:]
+			}
+		}
+		m.unread++
+		m.list = m.list.SetCount(len(m.errors))
+	}
+
+	// Same toast on screen already, it moves to newest.
+	for i := range m.toasts {
+		if m.toasts[i].msg.Source == msg.Source && m.toasts[i].msg.Text == msg.Text {
+			m.toasts = append(m.toasts[:i:i], m.toasts[i+1:]...)
+			break
+		}
+	}
+
+	id := m.nextID
+	m.nextID++
+	m.toasts = append(m.toasts, toast{id: id, msg: msg})
+	if len(m.toasts) > maxToasts {
+		m.toasts = m.toasts[len(m.toasts)-maxToasts:]
+	}
+	return m, tea.Tick(toastDuration, func(time.Time) tea.Msg { return ExpireMsg{id: id} })
+}
+
+// Errors not seen in error log yet.
+func (m Model) Unread() int {
+	return m.unread
+}
+
+// Mark every error read, call when error log is shown.
+func (m Model) Read() Model {
+	m.unread = 0
+	return m
+}
+
+// Draw toasts over bottom right corner of view.
+func (m Model) Overlay(view string, width int, height int) string {
+	if len(m.toasts) == 0 {
+		return view
+	}
+
+	boxes := make([]string, len(m.toasts))
+	for i, t := range m.toasts {
+		text := sourceStyle.Render(t.msg.Source) + " " + t.msg.Text
+		boxes[i] = toastStyle.Inherit(levelStyle[t.msg.Level]).Render(text)
+	}
+	box := lipgloss.JoinVertical(lipgloss.Left, boxes...)
+
+	lines := strings.Split(view, "\n")
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	boxLines := strings.Split(box, "\n")
+	x := max(0, width-lipgloss.Width(box))
+	y := max(0, height-len(boxLines))
+	for i, b := range boxLines {
+		if y+i >= len(lines) {
+			break
+		}
+		line := lines[y+i]
+		left := ansi.Truncate(line, x, "")
+		left += strings.Repeat(" ", max(0, x-ansi.StringWidth(left)))
+		lines[y+i] = left + b + ansi.TruncateLeft(line, x+ansi.StringWidth(b), "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Error log, newest at bottom, fits in width and height.
+func (m Model) LogView(width int, height int) string {
+	if len(m.errors) == 0 {
+		return "No error."
+	}
+
+	m.list = m.list.SetHeight(height - 1)
+	row := lipgloss.NewStyle().MaxWidth(width)
+	var builder strings.Builder
+	start, end := m.list.Range()
+	for _, e := range m.errors[start:end] {
+		line := timeStyle.Render(e.Time.Format(time.DateTime)) + " " + sourceStyle.Render(e.Source) + " " + e.Text
+		if e.count > 1 {
+			line += fmt.Sprintf(" (x%d)", e.count)
+		}
+		builder.WriteString(row.Render(line) + "\n")
+	}
+	builder.WriteString(m.list.Indicator())
+	return builder.String()
+}
+
+// Scroll error log to newest error, height is what LogView gets.
+func (m Model) ScrollEnd(height int) Model {
+	m.list = m.list.SetHeight(height - 1).SetCursor(len(m.errors) - 1)
+	return m
+}
@@ -0,0 +1,46 @@
+package notify
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type Level int
+
+const (
+	Info Level = iota
+	Warning
+	Error
+)
+
+func (l Level) String() string {
+	switch l {
+	case Warning:
+		return "WARN"
+	case Error:
+		return "ERROR"
+	default:
+		return "INFO"
+	}
+}
+
+// Use to deliver a notification to app, shown as a toast, errors are kept in error log.
+type Msg struct {
+	Time   time.Time
+	Source string
+	Level  Level
+	Text   string
+}
+
+// Command notify app, source is the component sending it.
+func Send(source string, level Level, text string) tea.Cmd {
+	return func() tea.Msg {
+		return Msg{Time: time.Now(), Source: source, Level: level, Text: text}
+	}
+}
+
+// Command notify app of an error.
+func Err(source string, err error) tea.Cmd {
+	return Send(source, Error, err.Error())
+}
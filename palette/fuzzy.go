@@ -0,0 +1,61 @@
+package palette
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Score of text matching pattern as a subsequence, ok is false if it doesn't.
+// Consecutive letters and letters at word start score higher.
+func score(pattern, text string) (int, bool) {
+	if pattern == "" {
+		return 0, true
+	}
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(text)
+
+	result, pi, last := 0, 0, -2
+	for ti := 0; ti < len(t) && pi < len(p); ti++ {
+		if unicode.ToLower(t[ti]) != p[pi] {
+			continue
+		}
+		result++
+		if ti == last+1 {
+			result += 3
+		}
+		if ti == 0 || !unicode.IsLetter(t[ti-1]) {
+			result += 2
+		}
+		last = ti
+		pi++
+	}
+	if pi < len(p) {
+		return 0, false
+	}
+	// Prefer shorter titles on equal match.
+	return result*100 - len(t), true
+}
+
+// Indexes of titles matching pattern, best first.
+func match(pattern string, titles []string) []int {
+	type scored struct {
+		index int
+		score int
+	}
+	found := make([]scored, 0, len(titles))
+	for i, title := range titles {
+		if s, ok := score(pattern, title); ok {
+			found = append(found, scored{i, s})
+		}
+	}
+	if pattern != "" {
+		sort.SliceStable(found, func(i, j int) bool { return found[i].score > found[j].score })
+	}
+
+	result := make([]int, len(found))
+	for i := range found {
+		result[i] = found[i].index
+	}
+	return result
+}
@@ -0,0 +1,72 @@
+package palette
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		text    string
+		wantOK  bool
+	}{
+		{"empty pattern", "", "Quit", true},
+		{"prefix", "sw", "Switch to tab keys", true},
+		{"subsequence", "stk", "Switch to tab keys", true},
+		{"ignores case", "QUIT", "Quit", true},
+		{"out of order", "tiuq", "Quit", false},
+		{"missing letter", "quix", "Quit", false},
+		{"longer than text", "quits", "Quit", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := score(tt.pattern, tt.text); ok != tt.wantOK {
+				t.Errorf("ok %v, want %v", ok, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestScoreOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		better  string
+		worse   string
+	}{
+		{"consecutive over scattered", "tab", "Table", "To a bar"},
+		{"word start over middle", "t", "Toggle help", "Quit"},
+		{"shorter on equal match", "quit", "Quit", "Quit now"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, okB := score(tt.pattern, tt.better)
+			w, okW := score(tt.pattern, tt.worse)
+			if !okB || !okW || b <= w {
+				t.Errorf("%q scores %d, %q scores %d, want first higher", tt.better, b, tt.worse, w)
+			}
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	titles := []string{"Quit", "Switch to tab keys", "Toggle help", "Split with tab keys"}
+	tests := []struct {
+		name    string
+		pattern string
+		want    []int
+	}{
+		{"empty keeps order", "", []int{0, 1, 2, 3}},
+		{"best first", "tk", []int{1, 3}},
+		{"no match", "zzz", []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := match(tt.pattern, titles); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package palette
+
+import (
+	"gw/dispatcher/debugger/keymap"
+	"gw/dispatcher/debugger/theme"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	boxStyle    = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+	sourceStyle = lipgloss.NewStyle().Faint(true)
+)
+
+// Rebuilt on theme change.
+var selectedStyle lipgloss.Style
+
+func init() {
+	theme.OnChange(func(t theme.Theme) {
+		selectedStyle = t.On(t.PanelLight)
+	})
+}
+
+// Rows of matched actions shown.
+const maxRows = 12
+
+// One action in palette.
+type Item struct {
+	// Tab name, or empty for app action.
+	Source string
+	Title  string
+
+	// Index of tab receiving Msg, -1 means app.
+	Tab int
+	Msg tea.Msg
+}
+
+func (i *Item) text() string {
+	if i.Source == "" {
+		return i.Title
+	}
+	return i.Source + ": " + i.Title
+}
+
+type keyMap struct {
+	Up    key.Binding
+	Down  key.Binding
+	Run   key.Binding
+	Close key.Binding
+}
+
+type Model struct {
+	bindings keyMap
+	open     bool
+	input    textinput.Model
+
+	items   []Item
+	matched []int
+	csr     int
+}
+
+func New() Model {
+	input := textinput.New()
+	input.Prompt = "> "
+	input.Placeholder = "type to search actions"
+
+	return Model{
+		bindings: keyMap{
+			Up:    keymap.New("palette.up", "move up", "up", "ctrl+k"),
+			Down:  keymap.New("palette.down", "move down", "down", "ctrl+j"),
+			Run:   keymap.New("palette.run", "run", "enter"),
+			Close: keymap.New("palette.close", "close", "esc", "ctrl+p"),
+		},
+		input: input,
+	}
+}
+
+// Open palette with actions available now.
+func (m Model) Open(items []Item) (Model, tea.Cmd) {
+	m.open = true
+	m.items = items
+	m.input.SetValue("")
+	m.filter()
+	return m, m.input.Focus()
+}
+
+func (m Model) Opened() bool {
+	return m.open
+}
+
+func (m *Model) filter() {
+	texts := make([]string, len(m.items))
+	for i := range m.items {
+		texts[i] = m.items[i].text()
+	}
+	m.matched = match(m.input.Value(), texts)
+	m.csr = 0
+}
+
+// Handle key, chosen is not nil when an action is picked and palette closes.
+func (m Model) Update(msg tea.KeyMsg) (Model, *Item, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.bindings.Close):
+		m.open = false
+		m.input.Blur()
+		return m, nil, nil
+	case key.Matches(msg, m.bindings.Run):
+		if len(m.matched) == 0 {
+			return m, nil, nil
+		}
+		chosen := m.items[m.matched[m.csr]]
+		m.open = false
+		m.input.Blur()
+		return m, &chosen, nil
+	case key.Matches(msg, m.bindings.Up):
+		if m.csr > 0 {
+			m.csr--
+		}
+		return m, nil, nil
+	case key.Matches(msg, m.bindings.Down):
+		if m.csr < len(m.matched)-1 {
+			m.csr++
+		}
+		return m, nil, nil
+	}
+
+	before := m.input.Value()
+	var c tea.Cmd
+	m.input, c = m.input.Update(msg)
+	if m.input.Value() != before {
+		m.filter()
+	}
+	return m, nil, c
+}
+
+func (m Model) View(width int) string {
+	width = max(20, min(width-4, 80))
+
+	var builder strings.Builder
+	builder.WriteString(m.input.View() + "\n\n")
+
+	offset := max(0, m.csr-maxRows+1)
+	end := min(offset+maxRows, len(m.matched))
+	row := lipgloss.NewStyle().Width(width).MaxWidth(width)
+	for i := offset; i < end; i++ {
+		item := &m.items[m.matched[i]]
+		line := item.Title
+		if item.Source != "" {
+			line = sourceStyle.Render(item.Source+": ") + item.Title
+		}
+		if i == m.csr {
+			line = selectedStyle.Render(item.text())
+		}
+		builder.WriteString(row.Render(line) + "\n")
+	}
+	if len(m.matched) == 0 {
+		builder.WriteString("No matching action.\n")
+	}
+	builder.WriteString(sourceStyle.Render(keymap.HelpText(m.bindings.Run, m.bindings.Up, m.bindings.Down, m.bindings.Close)))
+
+	return boxStyle.Width(width + 2).Render(builder.String())
+}
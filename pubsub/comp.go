@@ -0,0 +1,519 @@
+package pubsub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"gw/dispatcher/debugger/keymap"
+	"gw/dispatcher/debugger/msgs"
+	"gw/dispatcher/debugger/notify"
+	"gw/dispatcher/debugger/style"
+	"gw/dispatcher/debugger/tab"
+	"gw/dispatcher/debugger/theme"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	channelStyle = style.W().XL
+	countStyle   = style.W().S.Align(lipgloss.Right)
+	timeStyle    = style.W().M
+	msgChanStyle = style.W().L.Bold(true)
+	statusStyle  = style.W().L.Padding(0, 1)
+)
+
+// Rebuilt on theme change.
+var (
+	sectionStyle  lipgloss.Style
+	selectedStyle lipgloss.Style
+)
+
+func init() {
+	theme.OnChange(func(t theme.Theme) {
+		sectionStyle = t.On(t.BackgroundInverse).Bold(true)
+		selectedStyle = t.On(t.PanelLight)
+	})
+}
+
+const (
+	// Refresh active channels period in second.
+	refreshPeriod = 5
+
+	// Messages kept in log.
+	maxMessages = 2000
+
+	// Rows of channel list.
+	channelRows = 8
+
+	// Input, headers and help lines around lists.
+	chromeHeight = 5
+)
+
+// Source of notifications from this tab.
+const notifySource = "pubsub"
+
+// Use to deliver active channels and their subscriber count.
+type channelsMsg struct {
+	Channels []channel
+	Patterns int64
+	Err      error
+}
+
+type channel struct {
+	Name        string
+	Subscribers int64
+}
+
+type subscribedMsg struct {
+	PubSub *redis.PubSub
+	Target string
+	Err    error
+}
+
+type messagesMsg struct {
+	PubSub   *redis.PubSub
+	Messages []message
+}
+
+// One received message.
+type message struct {
+	Time    time.Time
+	Channel string
+	Pattern string
+	Payload string
+}
+
+type keyMap struct {
+	keymap.Common
+
+	Subscribe   key.Binding
+	Selected    key.Binding
+	Unsubscribe key.Binding
+	Clear       key.Binding
+	Search      key.Binding
+	Submit      key.Binding
+	Done        key.Binding
+	PageUp      key.Binding
+	PageDown    key.Binding
+	End         key.Binding
+}
+
+func newKeyMap() keyMap {
+	return keyMap{
+		Common:      keymap.NewCommon(),
+		Subscribe:   keymap.New("pubsub.subscribe", "subscribe", "s"),
+		Selected:    keymap.New("pubsub.selected", "subscribe selected", "enter"),
+		Unsubscribe: keymap.New("pubsub.unsubscribe", "unsubscribe all", "u"),
+		Clear:       keymap.New("pubsub.clear", "clear log", "c"),
+		Search:      keymap.New("pubsub.search", "search", "/"),
+		Submit:      keymap.New("pubsub.submit", "subscribe", "enter"),
+		Done:        keymap.New("pubsub.done", "done", "enter", "esc"),
+		PageUp:      keymap.New("pubsub.page_up", "log page up", "pgup"),
+		PageDown:    keymap.New("pubsub.page_down", "log page down", "pgdown"),
+		End:         keymap.New("pubsub.end", "newest", "end"),
+	}
+}
+
+type Model struct {
+	bindings keyMap
+	rdb      *redis.Client
+
+	channels []channel
+	patterns int64
+	csr      int
+	// Error of last channel list, channels listed before are kept.
+	listErr error
+	// Error of last subscribe.
+	err error
+
+	pubsub        *redis.PubSub
+	subscriptions []string
+
+	messages []message
+	// Messages from bottom the log is scrolled.
+	scroll int
+
+	input       textinput.Model
+	searchInput textinput.Model
+
+	height int
+	width  int
+}
+
+func New() Model {
+	input := textinput.New()
+	input.Prompt = "subscribe> "
+	input.Placeholder = "channel, or pattern with * ? []"
+
+	searchInput := textinput.New()
+	searchInput.Prompt = "search> "
+
+	return Model{bindings: newKeyMap(), input: input, searchInput: searchInput}
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+// Run command after a delay, unit is seconds.
+func delayRunCommand(sec time.Duration, cmd tea.Cmd) tea.Cmd {
+	return func() tea.Msg {
+		time.Sleep(sec * time.Second)
+		return cmd()
+	}
+}
+
+// Command read PUBSUB CHANNELS, NUMSUB and NUMPAT.
+func listChannels(rdb *redis.Client) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+
+		names, err := rdb.PubSubChannels(ctx, "*").Result()
+		if err != nil {
+			return channelsMsg{Err: err}
+		}
+		result := channelsMsg{}
+		if len(names) != 0 {
+			counts, err := rdb.PubSubNumSub(ctx, names...).Result()
+			if err != nil {
+				return channelsMsg{Err: err}
+			}
+			for _, name := range names {
+				result.Channels = append(result.Channels, channel{Name: name, Subscribers: counts[name]})
+			}
+		}
+		sort.Slice(result.Channels, func(i, j int) bool { return result.Channels[i].Name < result.Channels[j].Name })
+
+		result.Patterns, result.Err = rdb.PubSubNumPat(ctx).Result()
+		return result
+	}
+}
+
+func isPattern(target string) bool {
+	return strings.ContainsAny(target, "*?[")
+}
+
+// Command subscribe target on ps, a new PubSub is opened if ps is nil.
+func subscribe(rdb *redis.Client, ps *redis.PubSub, target string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		if ps == nil {
+			ps = rdb.Subscribe(ctx)
+		}
+
+		var err error
+		if isPattern(target) {
+			err = ps.PSubscribe(ctx, target)
+		} else {
+			err = ps.Subscribe(ctx, target)
+		}
+		return subscribedMsg{PubSub: ps, Target: target, Err: err}
+	}
+}
+
+// Command wait for a message, then take every message already arrived.
+func waitMessages(ps *redis.PubSub) tea.Cmd {
+	ch := ps.Channel()
+	return func() tea.Msg {
+		result := messagesMsg{PubSub: ps}
+
+		m, ok := <-ch
+		if !ok {
+			return nil
+		}
+		result.Messages = append(result.Messages, toMessage(m))
+
+		for {
+			select {
+			case m, ok := <-ch:
+				if !ok {
+					return result
+				}
+				result.Messages = append(result.Messages, toMessage(m))
+			default:
+				return result
+			}
+		}
+	}
+}
+
+func toMessage(m *redis.Message) message {
+	return message{Time: time.Now(), Channel: m.Channel, Pattern: m.Pattern, Payload: m.Payload}
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case msgs.RedisStateMsg:
+		m.rdb = msg.Client
+		if m.rdb == nil {
+			return m, nil
+		}
+		return m, listChannels(m.rdb)
+
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+		m.width = msg.Width
+		return m, nil
+
+	case channelsMsg:
+		next := delayRunCommand(refreshPeriod, listChannels(m.rdb))
+		// Tell error once, not on every retry.
+		failed := msg.Err != nil && m.listErr == nil
+		m.listErr = msg.Err
+		if msg.Err == nil {
+			m.channels = msg.Channels
+			m.patterns = msg.Patterns
+			m.csr = max(0, min(m.csr, len(m.channels)-1))
+		}
+		if failed {
+			return m, tea.Batch(next, notify.Err(notifySource, fmt.Errorf("list channels: %w", msg.Err)))
+		}
+		return m, next
+
+	case subscribedMsg:
+		if msg.Err != nil {
+			m.err = msg.Err
+			return m, notify.Err(notifySource, fmt.Errorf("subscribe %s: %w", msg.Target, msg.Err))
+		}
+		m.err = nil
+		m.subscriptions = append(m.subscriptions, msg.Target)
+		if m.pubsub == msg.PubSub {
+			return m, nil
+		}
+		// First subscription, start reading messages.
+		m.pubsub = msg.PubSub
+		return m, waitMessages(m.pubsub)
+
+	case messagesMsg:
+		if msg.PubSub != m.pubsub {
+			return m, nil
+		}
+		m.messages = append(m.messages, msg.Messages...)
+		if len(m.messages) > maxMessages {
+			m.messages = m.messages[len(m.messages)-maxMessages:]
+		}
+		if m.scroll != 0 {
+			m.scroll += len(msg.Messages)
+		}
+		return m, waitMessages(m.pubsub)
+
+	case paletteMsg:
+		if m.CapturingInput() {
+			return m, nil
+		}
+		return m.runAction(msg)
+
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+
+	return m, nil
+}
+
+func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.input.Focused() {
+		switch {
+		case key.Matches(msg, m.bindings.Back):
+			m.input.Blur()
+			return m, nil
+		case key.Matches(msg, m.bindings.Submit):
+			m.input.Blur()
+			target := strings.TrimSpace(m.input.Value())
+			m.input.SetValue("")
+			if target == "" || m.rdb == nil {
+				return m, nil
+			}
+			return m, subscribe(m.rdb, m.pubsub, target)
+		}
+		var c tea.Cmd
+		m.input, c = m.input.Update(msg)
+		return m, c
+	}
+	if m.searchInput.Focused() {
+		if key.Matches(msg, m.bindings.Done) {
+			m.searchInput.Blur()
+			return m, nil
+		}
+		var c tea.Cmd
+		m.searchInput, c = m.searchInput.Update(msg)
+		return m, c
+	}
+
+	switch {
+	case key.Matches(msg, m.bindings.Up):
+		if m.csr > 0 {
+			m.csr--
+		}
+	case key.Matches(msg, m.bindings.Down):
+		if m.csr < len(m.channels)-1 {
+			m.csr++
+		}
+	case key.Matches(msg, m.bindings.Selected):
+		if len(m.channels) == 0 || m.rdb == nil {
+			return m, nil
+		}
+		return m, subscribe(m.rdb, m.pubsub, m.channels[m.csr].Name)
+	case key.Matches(msg, m.bindings.Subscribe):
+		return m.runAction(paletteSubscribe)
+	case key.Matches(msg, m.bindings.Search):
+		return m, m.searchInput.Focus()
+	case key.Matches(msg, m.bindings.Unsubscribe):
+		return m.runAction(paletteUnsubscribe)
+	case key.Matches(msg, m.bindings.Clear):
+		return m.runAction(paletteClear)
+	case key.Matches(msg, m.bindings.PageUp):
+		m.scroll += max(1, m.logHeight()-1)
+	case key.Matches(msg, m.bindings.PageDown):
+		m.scroll = max(0, m.scroll-max(1, m.logHeight()-1))
+	case key.Matches(msg, m.bindings.End):
+		m.scroll = 0
+	}
+	return m, nil
+}
+
+func (m Model) Help() help.KeyMap {
+	k := m.bindings
+	switch {
+	case m.input.Focused():
+		return keymap.Help{Short: []key.Binding{k.Submit, k.Back}, Full: [][]key.Binding{{k.Submit, k.Back}}}
+	case m.searchInput.Focused():
+		return keymap.Help{Short: []key.Binding{k.Done}, Full: [][]key.Binding{{k.Done}}}
+	}
+	return keymap.Help{
+		Short: []key.Binding{k.Subscribe, k.Selected, k.Unsubscribe, k.Search, k.Clear},
+		Full: [][]key.Binding{
+			{k.Up, k.Down, k.Selected},
+			{k.Subscribe, k.Unsubscribe, k.Search, k.Clear},
+			{k.PageUp, k.PageDown, k.End},
+		},
+	}
+}
+
+// Typing channel or search needs every key.
+func (m Model) CapturingInput() bool {
+	return m.input.Focused() || m.searchInput.Focused()
+}
+
+func (m Model) logHeight() int {
+	return max(1, m.height-channelRows-chromeHeight)
+}
+
+func (m Model) View() string {
+	if m.rdb == nil {
+		return "Redis disconnected."
+	}
+
+	var builder strings.Builder
+
+	// Active channels.
+	builder.WriteString(sectionStyle.Render(channelStyle.Render(
+		fmt.Sprintf("ACTIVE CHANNELS (%d pattern subscriptions)", m.patterns))+countStyle.Render("SUBS")) + "\n")
+	if m.listErr != nil {
+		builder.WriteString("Channel list is outdated: " + m.listErr.Error() + "\n")
+	}
+	if m.err != nil {
+		builder.WriteString(m.err.Error() + "\n")
+	}
+	if len(m.channels) == 0 {
+		builder.WriteString("No active channel.\n")
+	}
+	offset := max(0, m.csr-channelRows+1)
+	for i := offset; i < min(offset+channelRows, len(m.channels)); i++ {
+		line := channelStyle.Render(m.channels[i].Name) + countStyle.Render(fmt.Sprintf("%d", m.channels[i].Subscribers))
+		if i == m.csr {
+			line = selectedStyle.Render(line)
+		}
+		builder.WriteString(line + "\n")
+	}
+
+	builder.WriteString(m.input.View() + "\n")
+	builder.WriteString(m.searchInput.View() + "\n")
+
+	// Message log, newest at bottom.
+	builder.WriteString(sectionStyle.Render(channelStyle.Render(
+		"SUBSCRIBED "+strings.Join(m.subscriptions, " "))) + "\n")
+
+	lines := make([]string, 0)
+	search := m.searchInput.Value()
+	row := lipgloss.NewStyle().MaxWidth(m.width)
+	for _, msg := range m.messages {
+		if search != "" && !strings.Contains(msg.Payload, search) && !strings.Contains(msg.Channel, search) {
+			continue
+		}
+		lines = append(lines, strings.Split(row.Render(messageRow(&msg)), "\n")...)
+	}
+
+	end := max(0, len(lines)-m.scroll)
+	start := max(0, end-m.logHeight())
+	builder.WriteString(strings.Join(lines[start:end], "\n"))
+
+	return builder.String()
+}
+
+func messageRow(msg *message) string {
+	from := msg.Channel
+	if msg.Pattern != "" {
+		from = fmt.Sprintf("%s (%s)", msg.Channel, msg.Pattern)
+	}
+	return timeStyle.Render(msg.Time.Format("15:04:05.000")) + msgChanStyle.Render(from) + prettyPayload(msg.Payload)
+}
+
+// Indent payload if it's JSON.
+func prettyPayload(payload string) string {
+	trimmed := strings.TrimSpace(payload)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return payload
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(trimmed), "", "  "); err != nil {
+		return payload
+	}
+	return "\n" + buf.String()
+}
+
+// Use to run palette action, keys of the same action run it too.
+type paletteMsg int
+
+const (
+	paletteSubscribe paletteMsg = iota
+	paletteUnsubscribe
+	paletteClear
+)
+
+func (m Model) runAction(a paletteMsg) (tea.Model, tea.Cmd) {
+	switch a {
+	case paletteSubscribe:
+		return m, m.input.Focus()
+	case paletteUnsubscribe:
+		if m.pubsub != nil {
+			m.pubsub.Close()
+			m.pubsub = nil
+		}
+		m.subscriptions = nil
+	case paletteClear:
+		m.messages = nil
+		m.scroll = 0
+	}
+	return m, nil
+}
+
+func (m Model) Actions() []tab.Action {
+	return []tab.Action{
+		{Title: "Subscribe channel or pattern", Msg: paletteSubscribe},
+		{Title: "Unsubscribe all", Msg: paletteUnsubscribe},
+		{Title: "Clear message log", Msg: paletteClear},
+	}
+}
+
+func (m Model) StatusBarView() string {
+	return statusStyle.Render(fmt.Sprintf("%d subs %d msgs", len(m.subscriptions), len(m.messages)))
+}
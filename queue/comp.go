@@ -3,10 +3,15 @@ package queue
 import (
 	"context"
 	"fmt"
+	"gw/dispatcher/debugger/keymap"
 	"gw/dispatcher/debugger/msgs"
+	"gw/dispatcher/debugger/notify"
 	"gw/dispatcher/debugger/style"
+	"gw/dispatcher/debugger/tab"
 	"time"
 
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/redis/go-redis/v9"
@@ -28,20 +33,51 @@ const (
 // Check queue status period in second.
 const checkPeriod = 1
 
+// Source of notifications from this tab.
+const notifySource = "queue"
+
+type keyMap struct {
+	keymap.Common
+	Trace  key.Binding
+	Submit key.Binding
+}
+
+func newKeyMap() keyMap {
+	return keyMap{
+		Common: keymap.NewCommon(),
+		Trace:  keymap.New("queue.trace", "trace task", "t"),
+		Submit: keymap.New("queue.submit", "trace", "enter"),
+	}
+}
+
+// Use to open trace task from palette.
+type openTraceMsg struct{}
+
 type Model struct {
-	rdb    *redis.Client
-	status msgs.StreamUpdateMsg
+	bindings keyMap
+	rdb      *redis.Client
+	status   msgs.StreamUpdateMsg
+
+	traces     map[string]*taskTrace
+	latencyErr error
+
+	trace trace
 }
 
 func New() Model {
-	return Model{}
+	return Model{bindings: newKeyMap(), traces: make(map[string]*taskTrace), trace: newTrace()}
 }
 
 func (m Model) View() string {
+	if m.trace.stage != traceClosed {
+		return m.trace.View(&m.bindings)
+	}
 	return lipgloss.JoinVertical(lipgloss.Left,
 		buildCol("Task Create", &m.status.TaskCreate),
 		buildCol("Infer Down", &m.status.InferDown),
 		buildCol("Postprocess Down", &m.status.ProcessDown),
+		"",
+		buildLatencyTable(m.traces, m.latencyErr),
 	)
 }
 
@@ -53,16 +89,87 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case msgs.RedisStateMsg:
 		m.rdb = msg.Client
-		return m, checkQueueStatus(m.rdb)
+		return m, tea.Batch(checkQueueStatus(m.rdb), sampleLatency(m.rdb))
 
 	case msgs.StreamUpdateMsg:
+		cmds := []tea.Cmd{delayRunCommand(checkPeriod, checkQueueStatus(m.rdb))}
+		// Tell error of each stream once, not on every check.
+		for _, s := range []struct {
+			key       string
+			last, now *msgs.ReadgroupStatus
+		}{
+			{taskQueueName, &m.status.TaskCreate, &msg.TaskCreate},
+			{inferCompleteQueueName, &m.status.InferDown, &msg.InferDown},
+			{postprocessComplelteQueueName, &m.status.ProcessDown, &msg.ProcessDown},
+		} {
+			if s.now.Err != nil && s.last.Err == nil {
+				cmds = append(cmds, notify.Err(notifySource, fmt.Errorf("inspect %s: %w", s.key, s.now.Err)))
+			}
+		}
 		m.status = msg
-		return m, delayRunCommand(checkPeriod, checkQueueStatus(m.rdb))
+		return m, tea.Batch(cmds...)
+
+	case latencySampleMsg:
+		next := delayRunCommand(checkPeriod, sampleLatency(m.rdb))
+		failed := msg.Err != nil && m.latencyErr == nil
+		m.latencyErr = msg.Err
+		if failed {
+			return m, tea.Batch(next, notify.Err(notifySource, fmt.Errorf("sample latency: %w", msg.Err)))
+		}
+		if msg.Err == nil {
+			updateTraces(m.traces, &msg)
+		}
+		return m, next
+
+	case openTraceMsg:
+		var c tea.Cmd
+		m.trace, c = m.trace.open()
+		return m, c
+
+	case traceDoneMsg:
+		var c tea.Cmd
+		m.trace, c = m.trace.Update(msg, m.rdb, &m.bindings)
+		if msg.Err != nil {
+			c = tea.Batch(c, notify.Err(notifySource, fmt.Errorf("trace %s: %w", msg.TaskID, msg.Err)))
+		}
+		return m, c
+
+	case tea.KeyMsg:
+		if m.trace.stage != traceClosed {
+			var c tea.Cmd
+			m.trace, c = m.trace.Update(msg, m.rdb, &m.bindings)
+			return m, c
+		}
+		if key.Matches(msg, m.bindings.Trace) {
+			var c tea.Cmd
+			m.trace, c = m.trace.open()
+			return m, c
+		}
 	}
 
 	return m, nil
 }
 
+func (m Model) Actions() []tab.Action {
+	return []tab.Action{{Title: "Trace task", Msg: openTraceMsg{}}}
+}
+
+func (m Model) Help() help.KeyMap {
+	k := m.bindings
+	switch m.trace.stage {
+	case traceInput:
+		return keymap.Help{Short: []key.Binding{k.Submit, k.Back}, Full: [][]key.Binding{{k.Submit, k.Back}}}
+	case traceLoading, traceShown:
+		return keymap.Help{Short: []key.Binding{k.Trace, k.Back}, Full: [][]key.Binding{{k.Trace, k.Back}}}
+	}
+	return keymap.Help{Short: []key.Binding{k.Trace}, Full: [][]key.Binding{{k.Trace}}}
+}
+
+// Typing task id needs every key.
+func (m Model) CapturingInput() bool {
+	return m.trace.stage == traceInput
+}
+
 // Run command after a delay, unit is seconds.
 func delayRunCommand(sec time.Duration, cmd tea.Cmd) tea.Cmd {
 	return func() tea.Msg {
@@ -0,0 +1,254 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"gw/dispatcher/debugger/style"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	latencyTitleStyle = style.W().L
+	latencyColStyle   = style.W().M.Align(lipgloss.Right)
+)
+
+const (
+	// Entry field which holds task id, use to correlate one task across stages.
+	taskIDField = "task_id"
+
+	// Pattern of runner streams, a dispatched task shows up in one of them.
+	runnerStreamPattern = "*::runner::stream::gw"
+
+	// How many recent entries read from each stream every sample.
+	sampleSize = 200
+
+	// Only tasks seen in this window are used to calculate latency.
+	latencyWindow = 5 * time.Minute
+)
+
+// Use to deliver sampled entry time of each stage, keyed by task id.
+type latencySampleMsg struct {
+	Created    map[string]time.Time
+	Dispatched map[string]time.Time
+	Inferred   map[string]time.Time
+	Processed  map[string]time.Time
+	Err        error
+}
+
+// Times one task reach each stage, zero if not seen yet.
+type taskTrace struct {
+	created    time.Time
+	dispatched time.Time
+	inferred   time.Time
+	processed  time.Time
+}
+
+// Latest time this task has been seen.
+func (t *taskTrace) lastSeen() time.Time {
+	last := t.created
+	for _, v := range []time.Time{t.dispatched, t.inferred, t.processed} {
+		if v.After(last) {
+			last = v
+		}
+	}
+	return last
+}
+
+// Command sample recent entries of all stages.
+func sampleLatency(rdb *redis.Client) tea.Cmd {
+	return func() tea.Msg {
+		result := latencySampleMsg{}
+
+		result.Created, result.Err = sampleStream(rdb, taskQueueName)
+		if result.Err != nil {
+			return result
+		}
+		result.Inferred, result.Err = sampleStream(rdb, inferCompleteQueueName)
+		if result.Err != nil {
+			return result
+		}
+		result.Processed, result.Err = sampleStream(rdb, postprocessComplelteQueueName)
+		if result.Err != nil {
+			return result
+		}
+
+		// Task may be dispatched to any runner, collect from all runner streams.
+		keys, err := rdb.Keys(context.Background(), runnerStreamPattern).Result()
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		result.Dispatched = make(map[string]time.Time)
+		for _, key := range keys {
+			entries, err := sampleStream(rdb, key)
+			if err != nil {
+				result.Err = err
+				return result
+			}
+			mergeEarliest(result.Dispatched, entries)
+		}
+
+		return result
+	}
+}
+
+// Read recent entries of stream and return entry time keyed by task id.
+func sampleStream(rdb *redis.Client, key string) (map[string]time.Time, error) {
+	entries, err := rdb.XRevRangeN(context.Background(), key, "+", "-", sampleSize).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]time.Time)
+	for _, e := range entries {
+		id, ok := e.Values[taskIDField].(string)
+		if !ok {
+			continue
+		}
+		t, ok := entryTime(e.ID)
+		if !ok {
+			continue
+		}
+		mergeEarliest(result, map[string]time.Time{id: t})
+	}
+	return result, nil
+}
+
+// Keep the earliest time of each task, a task may be delivered more than once.
+func mergeEarliest(dst, src map[string]time.Time) {
+	for id, t := range src {
+		if old, ok := dst[id]; !ok || t.Before(old) {
+			dst[id] = t
+		}
+	}
+}
+
+// Stream entry id looks like "<ms>-<seq>", the first part is a unix timestamp in millisecond.
+func entryTime(id string) (time.Time, bool) {
+	ms, _, _ := strings.Cut(id, "-")
+	v, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(v), true
+}
+
+// Merge sample into traces and drop tasks out of window.
+func updateTraces(traces map[string]*taskTrace, msg *latencySampleMsg) {
+	get := func(id string) *taskTrace {
+		t, ok := traces[id]
+		if !ok {
+			t = &taskTrace{}
+			traces[id] = t
+		}
+		return t
+	}
+
+	for id, v := range msg.Created {
+		get(id).created = v
+	}
+	for id, v := range msg.Dispatched {
+		if t := get(id); t.dispatched.IsZero() {
+			t.dispatched = v
+		}
+	}
+	for id, v := range msg.Inferred {
+		if t := get(id); t.inferred.IsZero() {
+			t.inferred = v
+		}
+	}
+	for id, v := range msg.Processed {
+		if t := get(id); t.processed.IsZero() {
+			t.processed = v
+		}
+	}
+
+	deadline := time.Now().Add(-latencyWindow)
+	for id, t := range traces {
+		if t.lastSeen().Before(deadline) {
+			delete(traces, id)
+		}
+	}
+}
+
+// Latency of one stage.
+type stageLatency struct {
+	Title   string
+	Samples []time.Duration
+}
+
+// Collect latency of each stage from traces, sample only counts when both ends are known.
+func collectLatency(traces map[string]*taskTrace) []stageLatency {
+	stages := []stageLatency{
+		{Title: "Dispatch Wait"},
+		{Title: "Inference"},
+		{Title: "Postprocess"},
+		{Title: "Total"},
+	}
+
+	add := func(i int, from, to time.Time) {
+		if from.IsZero() || to.IsZero() || to.Before(from) {
+			return
+		}
+		stages[i].Samples = append(stages[i].Samples, to.Sub(from))
+	}
+
+	for _, t := range traces {
+		add(0, t.created, t.dispatched)
+		add(1, t.dispatched, t.inferred)
+		add(2, t.inferred, t.processed)
+		add(3, t.created, t.processed)
+	}
+
+	for i := range stages {
+		sort.Slice(stages[i].Samples, func(a, b int) bool {
+			return stages[i].Samples[a] < stages[i].Samples[b]
+		})
+	}
+	return stages
+}
+
+// Nearest-rank percentile, samples must be sorted.
+func percentile(samples []time.Duration, p float64) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+	rank := int(p*float64(len(samples))+0.999999) - 1
+	rank = max(0, min(rank, len(samples)-1))
+	return samples[rank]
+}
+
+func buildLatencyTable(traces map[string]*taskTrace, err error) string {
+	header := lipgloss.JoinHorizontal(lipgloss.Top,
+		latencyTitleStyle.Render(fmt.Sprintf("Latency (last %s):", latencyWindow)),
+		latencyColStyle.Render("p50"),
+		latencyColStyle.Render("p90"),
+		latencyColStyle.Render("p99"),
+		latencyColStyle.Render("samples"),
+	)
+	if err != nil {
+		return lipgloss.JoinVertical(lipgloss.Left, header, err.Error())
+	}
+
+	rows := []string{header}
+	for _, stage := range collectLatency(traces) {
+		cols := []string{latencyTitleStyle.Render(stage.Title)}
+		for _, p := range []float64{0.5, 0.9, 0.99} {
+			text := "-"
+			if len(stage.Samples) != 0 {
+				text = percentile(stage.Samples, p).Round(time.Millisecond).String()
+			}
+			cols = append(cols, latencyColStyle.Render(text))
+		}
+		cols = append(cols, latencyColStyle.Render(fmt.Sprintf("%d", len(stage.Samples))))
+		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, cols...))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+}
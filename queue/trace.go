@@ -0,0 +1,200 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"gw/dispatcher/debugger/keymap"
+	"gw/dispatcher/debugger/style"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	traceTitle       = lipgloss.NewStyle().Bold(true)
+	traceHeader      = lipgloss.NewStyle().Bold(true)
+	traceStageStyle  = style.W().M
+	traceStreamStyle = style.W().XL
+	traceIDStyle     = style.W().L
+	traceTimeStyle   = style.W().M
+)
+
+// How many recent entries of each stream are searched for a task.
+const traceScanSize = 10000
+
+type traceStage int
+
+const (
+	traceClosed traceStage = iota
+	traceInput
+	traceLoading
+	traceShown
+)
+
+// One entry of traced task in a stream.
+type traceHop struct {
+	Stage  string
+	Stream string
+	ID     string
+	Time   time.Time
+}
+
+// Stream searched for a task and stage it stands for.
+type traceStream struct {
+	stage string
+	key   string
+}
+
+// Use to deliver where a task has been, oldest first.
+type traceDoneMsg struct {
+	TaskID string
+	Hops   []traceHop
+	Err    error
+}
+
+// Ask task id, then show every stream entry of that task.
+type trace struct {
+	stage  traceStage
+	input  textinput.Model
+	taskID string
+	hops   []traceHop
+	err    error
+}
+
+func newTrace() trace {
+	input := textinput.New()
+	input.Prompt = "task id> "
+	return trace{input: input}
+}
+
+func (t trace) open() (trace, tea.Cmd) {
+	t.stage = traceInput
+	t.input.SetValue(t.taskID)
+	t.input.CursorEnd()
+	return t, t.input.Focus()
+}
+
+// Command search recent entries of every stage stream for task id.
+func runTrace(rdb *redis.Client, taskID string) tea.Cmd {
+	return func() tea.Msg {
+		result := traceDoneMsg{TaskID: taskID}
+
+		runners, err := rdb.Keys(context.Background(), runnerStreamPattern).Result()
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		sort.Strings(runners)
+
+		streams := []traceStream{{"created", taskQueueName}}
+		for _, key := range runners {
+			streams = append(streams, traceStream{"dispatched", key})
+		}
+		streams = append(streams,
+			traceStream{"inferred", inferCompleteQueueName},
+			traceStream{"processed", postprocessComplelteQueueName},
+		)
+
+		for _, s := range streams {
+			entries, err := rdb.XRevRangeN(context.Background(), s.key, "+", "-", traceScanSize).Result()
+			if err != nil {
+				result.Err = fmt.Errorf("%s: %w", s.key, err)
+				return result
+			}
+			for _, e := range entries {
+				if id, _ := e.Values[taskIDField].(string); id != taskID {
+					continue
+				}
+				at, _ := entryTime(e.ID)
+				result.Hops = append(result.Hops, traceHop{Stage: s.stage, Stream: s.key, ID: e.ID, Time: at})
+			}
+		}
+
+		sort.SliceStable(result.Hops, func(i, j int) bool { return result.Hops[i].Time.Before(result.Hops[j].Time) })
+		return result
+	}
+}
+
+func (t trace) Update(msg tea.Msg, rdb *redis.Client, bindings *keyMap) (trace, tea.Cmd) {
+	switch msg := msg.(type) {
+	case traceDoneMsg:
+		if msg.TaskID != t.taskID || t.stage != traceLoading {
+			return t, nil
+		}
+		t.hops, t.err = msg.Hops, msg.Err
+		t.stage = traceShown
+		return t, nil
+
+	case tea.KeyMsg:
+		switch t.stage {
+		case traceInput:
+			switch {
+			case key.Matches(msg, bindings.Back):
+				t.input.Blur()
+				t.stage = traceClosed
+				return t, nil
+			case key.Matches(msg, bindings.Submit):
+				id := strings.TrimSpace(t.input.Value())
+				if id == "" || rdb == nil {
+					return t, nil
+				}
+				t.input.Blur()
+				t.taskID = id
+				t.stage = traceLoading
+				return t, runTrace(rdb, id)
+			}
+			var c tea.Cmd
+			t.input, c = t.input.Update(msg)
+			return t, c
+
+		case traceLoading, traceShown:
+			switch {
+			case key.Matches(msg, bindings.Back):
+				t.stage = traceClosed
+			case key.Matches(msg, bindings.Trace):
+				return t.open()
+			}
+		}
+	}
+
+	return t, nil
+}
+
+func (t trace) View(bindings *keyMap) string {
+	var builder strings.Builder
+	builder.WriteString(traceTitle.Render("Trace task") + "\n\n")
+
+	switch t.stage {
+	case traceInput:
+		builder.WriteString(t.input.View() + "\n\n")
+		builder.WriteString(keymap.HelpText(bindings.Submit, bindings.Back))
+		return builder.String()
+
+	case traceLoading:
+		builder.WriteString(fmt.Sprintf("Searching %s in last %d entries of each stream...", t.taskID, traceScanSize))
+		return builder.String()
+	}
+
+	switch {
+	case t.err != nil:
+		builder.WriteString(t.err.Error() + "\n")
+	case len(t.hops) == 0:
+		builder.WriteString(fmt.Sprintf("Task %s not found in last %d entries of any stream.\n", t.taskID, traceScanSize))
+	default:
+		builder.WriteString(traceHeader.Render(traceStageStyle.Render("STAGE")+traceStreamStyle.Render("STREAM")+
+			traceIDStyle.Render("ENTRY")+traceTimeStyle.Render("TIME")+"SINCE FIRST") + "\n")
+		first := t.hops[0].Time
+		for _, h := range t.hops {
+			builder.WriteString(traceStageStyle.Render(h.Stage) + traceStreamStyle.Render(h.Stream) + traceIDStyle.Render(h.ID) +
+				traceTimeStyle.Render(h.Time.Format("15:04:05.000")) + "+" + h.Time.Sub(first).String() + "\n")
+		}
+	}
+	builder.WriteString("\n" + keymap.HelpText(bindings.Trace, bindings.Back))
+	return builder.String()
+}
@@ -0,0 +1,92 @@
+package redisinfo
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// INFO reply grouped by section, section names are lower case.
+type Info map[string]map[string]string
+
+// Get value of field in section, empty if missing.
+func (i Info) Get(section, field string) string {
+	return i[section][field]
+}
+
+// Parse reply of INFO command.
+func ParseInfo(text string) Info {
+	result := make(Info)
+	section := ""
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			section = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(line, "#")))
+			result[section] = make(map[string]string)
+			continue
+		}
+
+		k, v, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		if result[section] == nil {
+			result[section] = make(map[string]string)
+		}
+		result[section][k] = v
+	}
+	return result
+}
+
+// One connection in CLIENT LIST.
+type Client struct {
+	ID   string
+	Addr string
+	Name string
+	Age  time.Duration
+	Idle time.Duration
+	DB   string
+	Cmd  string
+
+	// Every field as is.
+	Fields map[string]string
+}
+
+// Parse reply of CLIENT LIST command.
+func ParseClientList(text string) []Client {
+	result := make([]Client, 0)
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		fields := make(map[string]string)
+		for _, kv := range strings.Fields(line) {
+			k, v, _ := strings.Cut(kv, "=")
+			fields[k] = v
+		}
+
+		result = append(result, Client{
+			ID:     fields["id"],
+			Addr:   fields["addr"],
+			Name:   fields["name"],
+			Age:    seconds(fields["age"]),
+			Idle:   seconds(fields["idle"]),
+			DB:     fields["db"],
+			Cmd:    fields["cmd"],
+			Fields: fields,
+		})
+	}
+	return result
+}
+
+func seconds(s string) time.Duration {
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return time.Duration(n) * time.Second
+}
@@ -0,0 +1,405 @@
+package requeue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gw/dispatcher/debugger/keymap"
+	"gw/dispatcher/debugger/theme"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/redis/go-redis/v9"
+)
+
+// Default stream to requeue a message into.
+const DefaultTarget = "task_create::stream::gw"
+
+var (
+	formTitle = lipgloss.NewStyle().Bold(true)
+	formLabel = lipgloss.NewStyle().Bold(true).Width(10)
+)
+
+// Rebuilt on theme change.
+var confirmText lipgloss.Style
+
+func init() {
+	theme.OnChange(func(t theme.Theme) {
+		confirmText = t.On(t.Warning).Padding(0, 1)
+	})
+}
+
+type stage int
+
+const (
+	closed stage = iota
+	loading
+	editing
+	confirm
+	running
+)
+
+// Which widget of form has focus.
+const (
+	focusFields = iota
+	focusTarget
+)
+
+// Stream entry to requeue, Group is empty when entry isn't read by a group
+// and then it can't be acked.
+type Entry struct {
+	Stream string
+	Group  string
+	ID     string
+}
+
+// Use to deliver fields of entry going to be requeued.
+type LoadedMsg struct {
+	Entry  Entry
+	Values map[string]interface{}
+	Err    error
+}
+
+// Use to report requeue result.
+type DoneMsg struct {
+	Entry  Entry
+	Target string
+	NewID  string
+	Acked  bool
+	Err    error
+}
+
+type keyMap struct {
+	keymap.Common
+
+	Switch key.Binding
+	Ack    key.Binding
+	Submit key.Binding
+}
+
+func newKeyMap() keyMap {
+	return keyMap{
+		Common: keymap.NewCommon(),
+		Switch: keymap.New("requeue.switch", "switch field", "tab"),
+		Ack:    keymap.New("requeue.ack", "toggle ack", "ctrl+a"),
+		Submit: keymap.New("requeue.submit", "submit", "ctrl+s"),
+	}
+}
+
+// Form to edit and confirm a requeue.
+type Model struct {
+	bindings keyMap
+	stage    stage
+	entry    Entry
+
+	fields textarea.Model
+	target textinput.Model
+	focus  int
+	ack    bool
+
+	err error
+}
+
+func New(entry Entry) Model {
+	fields := textarea.New()
+	fields.ShowLineNumbers = false
+	fields.SetWidth(80)
+	fields.SetHeight(10)
+
+	target := textinput.New()
+	target.SetValue(DefaultTarget)
+
+	return Model{
+		bindings: newKeyMap(),
+		stage:    loading,
+		entry:    entry,
+		fields:   fields,
+		target:   target,
+		focus:    focusFields,
+		ack:      entry.Group != "",
+	}
+}
+
+// Form is shown and takes every key.
+func (r Model) Open() bool {
+	return r.stage != closed
+}
+
+// Close form, call when DoneMsg arrives.
+func (r Model) Close() Model {
+	r.stage = closed
+	return r
+}
+
+// Command read fields of an entry.
+func Load(rdb *redis.Client, entry Entry) tea.Cmd {
+	return func() tea.Msg {
+		result := LoadedMsg{Entry: entry}
+
+		messages, err := rdb.XRange(context.Background(), entry.Stream, entry.ID, entry.ID).Result()
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		if len(messages) == 0 {
+			result.Err = fmt.Errorf("entry %s not found in %s, maybe trimmed", entry.ID, entry.Stream)
+			return result
+		}
+		result.Values = messages[0].Values
+		return result
+	}
+}
+
+// Command add fields to target stream, then ack the original entry if asked.
+func run(rdb *redis.Client, entry Entry, target string, values []string, ack bool) tea.Cmd {
+	return func() tea.Msg {
+		result := DoneMsg{Entry: entry, Target: target}
+
+		result.NewID, result.Err = rdb.XAdd(context.Background(), &redis.XAddArgs{
+			Stream: target,
+			Values: values,
+		}).Result()
+		if result.Err != nil {
+			return result
+		}
+
+		if ack {
+			result.Err = rdb.XAck(context.Background(), entry.Stream, entry.Group, entry.ID).Err()
+			result.Acked = result.Err == nil
+		}
+		return result
+	}
+}
+
+// Render entry values as JSON object, so values keep their newlines and "=".
+func FormatValues(values map[string]interface{}) string {
+	fields := make(map[string]string, len(values))
+	for k, v := range values {
+		fields[k] = fmt.Sprint(v)
+	}
+	// Map keys are sorted by encoding/json.
+	data, _ := json.MarshalIndent(fields, "", "  ")
+	return string(data)
+}
+
+// Parse JSON object of string values into XADD values. Duplicated or empty
+// field, non string value and anything after the object are rejected, they
+// would make what's added differ from what's shown.
+func parseValues(text string) ([]string, error) {
+	dec := json.NewDecoder(strings.NewReader(text))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, errors.New(`fields must be a JSON object like {"field": "value"}`)
+	}
+
+	values := make([]string, 0)
+	seen := make(map[string]bool)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		field := tok.(string)
+		if field == "" {
+			return nil, errors.New("field name is empty")
+		}
+		if seen[field] {
+			return nil, fmt.Errorf("field %q given twice", field)
+		}
+		seen[field] = true
+
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return nil, fmt.Errorf("field %q: invalid JSON: %w", field, err)
+		}
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("field %q: value must be a JSON string, quote it", field)
+		}
+		values = append(values, field, s)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected text after fields object")
+	}
+
+	if len(values) == 0 {
+		return nil, errors.New("no fields to add")
+	}
+	return values, nil
+}
+
+func (r Model) Update(msg tea.Msg, rdb *redis.Client) (Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case LoadedMsg:
+		if msg.Entry != r.entry {
+			return r, nil
+		}
+		if msg.Err != nil {
+			r.err = msg.Err
+			return r, nil
+		}
+		r.stage = editing
+		r.fields.SetValue(FormatValues(msg.Values))
+		return r, r.fields.Focus()
+
+	case tea.KeyMsg:
+		return r.handleKey(msg, rdb)
+	}
+
+	return r, nil
+}
+
+func (r Model) handleKey(msg tea.KeyMsg, rdb *redis.Client) (Model, tea.Cmd) {
+	switch r.stage {
+	case loading:
+		if key.Matches(msg, r.bindings.Back) {
+			r.stage = closed
+		}
+		return r, nil
+
+	case confirm:
+		switch {
+		case key.Matches(msg, r.bindings.Confirm):
+			values, err := parseValues(r.fields.Value())
+			if err != nil {
+				r.err = err
+				r.stage = editing
+				return r, nil
+			}
+			r.stage = running
+			return r, run(rdb, r.entry, strings.TrimSpace(r.target.Value()), values, r.ack)
+		case key.Matches(msg, r.bindings.Cancel):
+			r.stage = editing
+		}
+		return r, nil
+
+	case editing:
+		switch {
+		case key.Matches(msg, r.bindings.Back):
+			r.stage = closed
+			return r, nil
+		case key.Matches(msg, r.bindings.Switch):
+			if r.focus == focusFields {
+				r.focus = focusTarget
+				r.fields.Blur()
+				return r, r.target.Focus()
+			}
+			r.focus = focusFields
+			r.target.Blur()
+			return r, r.fields.Focus()
+		case key.Matches(msg, r.bindings.Ack):
+			if r.entry.Group == "" {
+				r.err = errors.New("entry isn't read by a consumer group, nothing to ack")
+				return r, nil
+			}
+			r.ack = !r.ack
+			return r, nil
+		case key.Matches(msg, r.bindings.Submit):
+			if _, err := parseValues(r.fields.Value()); err != nil {
+				r.err = err
+				return r, nil
+			}
+			if strings.TrimSpace(r.target.Value()) == "" {
+				r.err = errors.New("target stream is empty")
+				return r, nil
+			}
+			r.err = nil
+			r.stage = confirm
+			return r, nil
+		}
+
+		var c tea.Cmd
+		if r.focus == focusFields {
+			r.fields, c = r.fields.Update(msg)
+		} else {
+			r.target, c = r.target.Update(msg)
+		}
+		return r, c
+	}
+
+	return r, nil
+}
+
+// Keys form takes in current stage.
+func (r Model) Help() help.KeyMap {
+	var bindings []key.Binding
+	switch r.stage {
+	case loading:
+		bindings = []key.Binding{r.bindings.Back}
+	case confirm:
+		bindings = []key.Binding{r.bindings.Confirm, r.bindings.Cancel}
+	case editing:
+		bindings = []key.Binding{r.bindings.Switch, r.bindings.Ack, r.bindings.Submit, r.bindings.Back}
+	}
+	return keymap.Help{Short: bindings, Full: [][]key.Binding{bindings}}
+}
+
+func (r Model) View() string {
+	var builder strings.Builder
+	builder.WriteString(formTitle.Render(fmt.Sprintf("Requeue %s from %s", r.entry.ID, r.entry.Stream)) + "\n\n")
+
+	switch r.stage {
+	case loading:
+		if r.err != nil {
+			builder.WriteString(r.err.Error() + "\n\n")
+			builder.WriteString(keymap.HelpText(r.bindings.Back))
+		} else {
+			builder.WriteString("Loading entry...")
+		}
+		return builder.String()
+
+	case running:
+		builder.WriteString("Requeuing...")
+		return builder.String()
+
+	case confirm:
+		builder.WriteString(formLabel.Render("Target") + strings.TrimSpace(r.target.Value()) + "\n")
+		builder.WriteString(formLabel.Render("Ack") + yesNo(r.ack) + "\n")
+		builder.WriteString(formLabel.Render("Fields") + "\n" + r.fields.Value() + "\n\n")
+		builder.WriteString(confirmText.Render("Add above entry to target stream? " + keymap.HelpText(r.bindings.Confirm, r.bindings.Cancel)))
+		return builder.String()
+	}
+
+	builder.WriteString(formLabel.Render("Fields") + "\n" + r.fields.View() + "\n")
+	builder.WriteString(formLabel.Render("Target") + r.target.View() + "\n")
+	builder.WriteString(formLabel.Render("Ack") + yesNo(r.ack) + "\n\n")
+	if r.err != nil {
+		builder.WriteString(r.err.Error() + "\n")
+	}
+	builder.WriteString(keymap.HelpText(r.bindings.Switch, r.bindings.Ack, r.bindings.Submit, r.bindings.Back))
+	return builder.String()
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// Describe requeue result for history.
+func (msg *DoneMsg) Describe() string {
+	now := time.Now().Format("15:04:05")
+	if msg.Err != nil && msg.NewID == "" {
+		return fmt.Sprintf("%s requeue %s %s failed: %s", now, msg.Entry.Stream, msg.Entry.ID, msg.Err.Error())
+	}
+
+	text := fmt.Sprintf("%s requeued %s %s -> %s %s", now, msg.Entry.Stream, msg.Entry.ID, msg.Target, msg.NewID)
+	switch {
+	case msg.Acked:
+		text += ", original acked"
+	case msg.Err != nil:
+		text += ", ack failed: " + msg.Err.Error()
+	}
+	return text
+}
@@ -0,0 +1,107 @@
+package runnerwatcher
+
+import (
+	"context"
+	"fmt"
+	"gw/dispatcher/debugger/redisinfo"
+	"net"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/redis/go-redis/v9"
+)
+
+var detailTitle = lipgloss.NewStyle().Bold(true)
+
+// Read client list period in second.
+const clientListPeriod = 2
+
+// Use to deliver redis connections.
+type ClientListMsg struct {
+	Clients []redisinfo.Client
+	Err     error
+}
+
+// Command read CLIENT LIST.
+func updateClientList(rdb *redis.Client) tea.Cmd {
+	return func() tea.Msg {
+		text, err := rdb.ClientList(context.Background()).Result()
+		if err != nil {
+			return ClientListMsg{Err: err}
+		}
+		return ClientListMsg{Clients: redisinfo.ParseClientList(text)}
+	}
+}
+
+// Connections belong to runner, matched by client name, or by address in addrs which
+// maps runner name to "host" or "host:port".
+func runnerClients(name string, clients []redisinfo.Client, addrs map[string]string) []redisinfo.Client {
+	result := make([]redisinfo.Client, 0)
+	mapped := addrs[name]
+
+	for _, c := range clients {
+		switch {
+		case c.Name != "" && c.Name == name:
+			result = append(result, c)
+		case mapped != "" && matchAddr(c.Addr, mapped):
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// Mapping without port matches any port of that host.
+func matchAddr(addr, mapped string) bool {
+	if addr == mapped {
+		return true
+	}
+	host, _, err := net.SplitHostPort(addr)
+	return err == nil && host == mapped
+}
+
+// The most recently active connection.
+func mostRecent(clients []redisinfo.Client) *redisinfo.Client {
+	var result *redisinfo.Client
+	for i := range clients {
+		if result == nil || clients[i].Idle < result.Idle {
+			result = &clients[i]
+		}
+	}
+	return result
+}
+
+// Text of CONN column.
+func connText(clients []redisinfo.Client) string {
+	c := mostRecent(clients)
+	if c == nil {
+		return "NONE"
+	}
+	text := fmt.Sprintf("%s %s %s", c.Addr, c.Idle, c.Cmd)
+	if len(clients) > 1 {
+		text = fmt.Sprintf("(%d) %s", len(clients), text)
+	}
+	return text
+}
+
+// Detail of every connection of selected runner.
+func connDetail(name string, clients []redisinfo.Client, err error) string {
+	var builder strings.Builder
+	builder.WriteString(detailTitle.Render(fmt.Sprintf("Connections of %s", name)) + "\n")
+
+	if err != nil {
+		builder.WriteString("Read client list error " + err.Error() + "\n")
+		return builder.String()
+	}
+	if len(clients) == 0 {
+		builder.WriteString("No connection found by client name or address mapping.\n")
+		return builder.String()
+	}
+
+	for _, c := range clients {
+		builder.WriteString(fmt.Sprintf("  id=%s addr=%s name=%s db=%s age=%s idle=%s cmd=%s\n",
+			c.ID, c.Addr, c.Name, c.DB, c.Age.Round(time.Second), c.Idle.Round(time.Second), c.Cmd))
+	}
+	return builder.String()
+}
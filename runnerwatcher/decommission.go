@@ -0,0 +1,290 @@
+package runnerwatcher
+
+import (
+	"context"
+	"fmt"
+	"gw/dispatcher/debugger/keymap"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/redis/go-redis/v9"
+)
+
+// Stream where every new task comes in, pending work can go back there.
+const taskQueueName = "task_create::stream::gw"
+
+// Pending entries read per XPENDING call.
+const pendingPageSize = 1000
+
+// Pending entries listed in dialog, the rest is only counted so the prompt fits.
+const maxListedPending = 10
+
+var dialogTitle = lipgloss.NewStyle().Bold(true)
+
+type decommissionStage int
+
+const (
+	decommissionClosed decommissionStage = iota
+	decommissionLoading
+	decommissionConfirm
+	decommissionRunning
+)
+
+// Use to deliver everything belongs to a runner.
+type decommissionLoadedMsg struct {
+	Name        string
+	Keys        []string
+	Pending     []redis.XPendingExt
+	Undelivered []string
+	Err         error
+}
+
+type decommissionDoneMsg struct {
+	Name    string
+	Moved   int
+	Deleted int64
+	Err     error
+}
+
+// Dialog to decommission one dead runner.
+type decommission struct {
+	bindings keyMap
+	stage    decommissionStage
+	name     string
+
+	keys        []string
+	pending     []redis.XPendingExt
+	undelivered []string
+
+	// Streams pending work can be moved to, empty target means drop it.
+	targets []string
+	target  int
+
+	err error
+}
+
+func newDecommission(name string, alive []string, bindings keyMap) decommission {
+	targets := []string{taskQueueName}
+	for _, n := range alive {
+		targets = append(targets, runnerStream(n))
+	}
+	targets = append(targets, "")
+
+	return decommission{bindings: bindings, stage: decommissionLoading, name: name, targets: targets}
+}
+
+func runnerStream(name string) string {
+	return fmt.Sprintf("%s::runner::stream::gw", name)
+}
+
+func runnerReadgroup(name string) string {
+	return fmt.Sprintf("%s::runner::readgroup::gw", name)
+}
+
+// Command collect keys and unfinished work of runner.
+func loadRunnerData(rdb *redis.Client, name string) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		result := decommissionLoadedMsg{Name: name}
+
+		result.Keys, result.Err = rdb.Keys(ctx, fmt.Sprintf("%s::runner::*", name)).Result()
+		if result.Err != nil {
+			return result
+		}
+
+		stream, group := runnerStream(name), runnerReadgroup(name)
+		typ, err := rdb.Type(ctx, stream).Result()
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		// No stream means no work left.
+		if typ == "none" {
+			return result
+		}
+		if typ != "stream" {
+			result.Err = fmt.Errorf("%s is a %s, not a stream", stream, typ)
+			return result
+		}
+
+		groups, err := rdb.XInfoGroups(ctx, stream).Result()
+		if err != nil {
+			result.Err = err
+			return result
+		}
+
+		for _, g := range groups {
+			if g.Name != group {
+				continue
+			}
+
+			// Page through every pending entry, each page starts after the last ID of previous one.
+			start := "-"
+			for {
+				page, err := rdb.XPendingExt(ctx, &redis.XPendingExtArgs{
+					Stream: stream,
+					Group:  group,
+					Start:  start,
+					End:    "+",
+					Count:  pendingPageSize,
+				}).Result()
+				if err != nil {
+					result.Err = err
+					return result
+				}
+				if len(page) == 0 {
+					break
+				}
+				result.Pending = append(result.Pending, page...)
+				start = "(" + page[len(page)-1].ID
+			}
+
+			// Entries never read by the runner are lost too if stream is deleted.
+			entries, err := rdb.XRange(ctx, stream, "("+g.LastDeliveredID, "+").Result()
+			if err != nil {
+				result.Err = err
+				return result
+			}
+			for _, e := range entries {
+				result.Undelivered = append(result.Undelivered, e.ID)
+			}
+		}
+		return result
+	}
+}
+
+// Command move unfinished work to target stream, then delete keys of runner.
+func runDecommission(rdb *redis.Client, d *decommission) tea.Cmd {
+	name, keys, target := d.name, d.keys, d.targets[d.target]
+	pending := make([]string, len(d.pending))
+	for i := range d.pending {
+		pending[i] = d.pending[i].ID
+	}
+	ids := append(pending, d.undelivered...)
+
+	return func() tea.Msg {
+		ctx := context.Background()
+		result := decommissionDoneMsg{Name: name}
+		stream, group := runnerStream(name), runnerReadgroup(name)
+
+		if target != "" {
+			for i, id := range ids {
+				entries, err := rdb.XRange(ctx, stream, id, id).Result()
+				if err != nil {
+					result.Err = err
+					return result
+				}
+				if len(entries) == 0 {
+					continue
+				}
+				if err := rdb.XAdd(ctx, &redis.XAddArgs{Stream: target, Values: entries[0].Values}).Err(); err != nil {
+					result.Err = err
+					return result
+				}
+				if i < len(pending) {
+					if err := rdb.XAck(ctx, stream, group, id).Err(); err != nil {
+						result.Err = err
+						return result
+					}
+				}
+				result.Moved++
+			}
+		}
+
+		if len(keys) != 0 {
+			result.Deleted, result.Err = rdb.Del(ctx, keys...).Result()
+		}
+		return result
+	}
+}
+
+func (d decommission) Update(msg tea.Msg, rdb *redis.Client) (decommission, tea.Cmd) {
+	switch msg := msg.(type) {
+	case decommissionLoadedMsg:
+		if msg.Name != d.name {
+			return d, nil
+		}
+		d.err = msg.Err
+		d.keys = msg.Keys
+		d.pending = msg.Pending
+		d.undelivered = msg.Undelivered
+		if d.err == nil {
+			d.stage = decommissionConfirm
+		}
+		return d, nil
+
+	case tea.KeyMsg:
+		switch d.stage {
+		case decommissionLoading:
+			if key.Matches(msg, d.bindings.Back) {
+				d.stage = decommissionClosed
+			}
+		case decommissionConfirm:
+			switch {
+			case key.Matches(msg, d.bindings.Target):
+				d.target = (d.target + 1) % len(d.targets)
+			case key.Matches(msg, d.bindings.Confirm):
+				d.stage = decommissionRunning
+				return d, runDecommission(rdb, &d)
+			case key.Matches(msg, d.bindings.Cancel):
+				d.stage = decommissionClosed
+			}
+		}
+	}
+
+	return d, nil
+}
+
+func (d decommission) View() string {
+	var builder strings.Builder
+	builder.WriteString(dialogTitle.Render("Decommission runner "+d.name) + "\n\n")
+
+	switch d.stage {
+	case decommissionLoading:
+		if d.err != nil {
+			builder.WriteString(d.err.Error() + "\n\n" + keymap.HelpText(d.bindings.Back))
+		} else {
+			builder.WriteString("Loading runner data...")
+		}
+		return builder.String()
+	case decommissionRunning:
+		builder.WriteString("Decommissioning...")
+		return builder.String()
+	}
+
+	// Prompt goes first, lists below may be clipped by a short screen.
+	target := d.targets[d.target]
+	if target == "" {
+		target = "(drop, do not reassign)"
+	}
+	builder.WriteString(dialogTitle.Render("Reassign work to ") + target + "\n")
+	builder.WriteString(keymap.HelpText(d.bindings.Target) + "\n")
+	builder.WriteString(confirmText.Render("Reassign work and delete below keys? "+keymap.HelpText(d.bindings.Confirm, d.bindings.Cancel)) + "\n\n")
+
+	builder.WriteString(dialogTitle.Render("Keys to delete") + "\n")
+	for _, key := range d.keys {
+		builder.WriteString("  " + key + "\n")
+	}
+	if len(d.keys) == 0 {
+		builder.WriteString("  (none)\n")
+	}
+
+	builder.WriteString("\n" + dialogTitle.Render(fmt.Sprintf("Pending entries of %s (%d)", runnerReadgroup(d.name), len(d.pending))) + "\n")
+	for _, p := range d.pending[:min(len(d.pending), maxListedPending)] {
+		builder.WriteString(fmt.Sprintf("  %s consumer=%s idle=%s delivered=%d\n",
+			p.ID, p.Consumer, p.Idle.Round(time.Second), p.RetryCount))
+	}
+	if len(d.pending) > maxListedPending {
+		builder.WriteString(fmt.Sprintf("  and %d more\n", len(d.pending)-maxListedPending))
+	}
+	if len(d.pending) == 0 {
+		builder.WriteString("  (none)\n")
+	}
+	if len(d.undelivered) != 0 {
+		builder.WriteString(fmt.Sprintf("  and %d entries never delivered\n", len(d.undelivered)))
+	}
+	return builder.String()
+}
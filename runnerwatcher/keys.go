@@ -0,0 +1,53 @@
+package runnerwatcher
+
+import (
+	"gw/dispatcher/debugger/keymap"
+	"gw/dispatcher/debugger/tab"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+)
+
+type keyMap struct {
+	keymap.Common
+	Decommission key.Binding
+	Target       key.Binding
+}
+
+func newKeyMap() keyMap {
+	return keyMap{
+		Common:       keymap.NewCommon(),
+		Decommission: keymap.New("runner.decommission", "decommission dead runner", "d"),
+		Target:       keymap.New("runner.target", "change target", "t"),
+	}
+}
+
+// Use to open decommission dialog from palette.
+type openDecommissionMsg struct{}
+
+func (m Model) Actions() []tab.Action {
+	result := make([]tab.Action, 0)
+	for _, c := range columns() {
+		if c.sort != sortNone {
+			result = append(result, tab.Action{Title: "Sort runners by " + c.desc, Msg: sortMsg(c.sort)})
+		}
+	}
+	return append(result, tab.Action{Title: "Decommission selected runner", Msg: openDecommissionMsg{}})
+}
+
+func (m Model) Help() help.KeyMap {
+	k := m.bindings
+	switch m.decommission.stage {
+	case decommissionLoading:
+		return keymap.Help{Short: []key.Binding{k.Back}, Full: [][]key.Binding{{k.Back}}}
+	case decommissionConfirm:
+		return keymap.Help{
+			Short: []key.Binding{k.Target, k.Confirm, k.Cancel},
+			Full:  [][]key.Binding{{k.Target, k.Confirm, k.Cancel}},
+		}
+	}
+	return keymap.Help{
+		Short: []key.Binding{k.Up, k.Down, k.Decommission},
+		Full:  [][]key.Binding{m.list.Bindings(), {k.Decommission}},
+	}
+}
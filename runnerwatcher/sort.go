@@ -0,0 +1,123 @@
+package runnerwatcher
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Column runner table is sorted by.
+type sortColumn int
+
+const (
+	// Alive and busy first, then newest.
+	sortStatus sortColumn = iota
+	sortName
+	sortModel
+	sortHeartbeat
+	sortPending
+	sortCtime
+	sortUtime
+
+	// Column can't sort.
+	sortNone sortColumn = -1
+)
+
+// Use to sort runner table.
+type sortMsg sortColumn
+
+// Column of runner table.
+type column struct {
+	title string
+	style lipgloss.Style
+	sort  sortColumn
+	// Used in palette.
+	desc string
+}
+
+func columns() []column {
+	return []column{
+		{"NAME", nameStyle, sortName, "name"},
+		{"MODEL", modelStyle, sortModel, "model"},
+		{"LIFE", heartbeatStyle, sortHeartbeat, "latest heartbeat"},
+		{"BUSY", stateStyle, sortStatus, "status"},
+		{"PEND", pendingStyle, sortPending, "pending count"},
+		{"CTIME", ctimeStyle, sortCtime, "create time"},
+		{"UTIME", utimeStyle, sortUtime, "update time"},
+		{"CONN", connStyle, sortNone, ""},
+	}
+}
+
+func stateTableHeader(width int, sortBy sortColumn) string {
+	var builder strings.Builder
+
+	for _, c := range columns() {
+		title := c.title
+		if c.sort == sortBy {
+			title += "▾"
+		}
+		builder.WriteString(c.style.Inherit(textInverseAndBold).Render(title))
+	}
+
+	// Fill the rest of this line.
+	return textInverse.Width(width).Render(builder.String())
+}
+
+func pendingCount(s *state) int64 {
+	if s.Pending == nil {
+		return -1
+	}
+	return s.Pending.Count
+}
+
+func sortState(states []state, by sortColumn) []state {
+	sort.SliceStable(states, func(i, j int) bool {
+		a, b := &states[i], &states[j]
+		switch by {
+		case sortName:
+			return a.Name < b.Name
+		case sortModel:
+			if a.Model != b.Model {
+				return a.Model < b.Model
+			}
+			return a.Name < b.Name
+		case sortHeartbeat:
+			if (a.Heartbeat == nil) != (b.Heartbeat == nil) {
+				return a.Heartbeat != nil
+			}
+			if a.Heartbeat != nil && !a.Heartbeat.Equal(*b.Heartbeat) {
+				return a.Heartbeat.After(*b.Heartbeat)
+			}
+			return a.Name < b.Name
+		case sortPending:
+			if pendingCount(a) != pendingCount(b) {
+				return pendingCount(a) > pendingCount(b)
+			}
+			return a.Name < b.Name
+		case sortCtime:
+			if !a.Ctime.Equal(b.Ctime) {
+				return a.Ctime.After(b.Ctime)
+			}
+			return a.Name < b.Name
+		case sortUtime:
+			if !a.Utime.Equal(b.Utime) {
+				return a.Utime.After(b.Utime)
+			}
+			return a.Name < b.Name
+		}
+
+		if isAlive(a) != isAlive(b) {
+			return isAlive(a)
+		}
+		if isAlive(a) && a.Busy != b.Busy {
+			return a.Busy
+		}
+		if a.Ctime.Unix() != b.Ctime.Unix() {
+			return a.Ctime.Unix() > b.Ctime.Unix()
+		}
+		return a.Name < b.Name
+	})
+
+	return states
+}
@@ -0,0 +1,22 @@
+package runnerwatcher
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSortStateBreaksTiesByName(t *testing.T) {
+	now := time.Now()
+	for _, by := range []sortColumn{sortStatus, sortName, sortModel, sortHeartbeat, sortPending, sortCtime, sortUtime} {
+		// Same in every column but name, in reverse order.
+		states := []state{
+			{Name: "c", Ctime: now, Utime: now},
+			{Name: "b", Ctime: now, Utime: now},
+			{Name: "a", Ctime: now, Utime: now},
+		}
+		got := sortState(states, by)
+		if got[0].Name != "a" || got[1].Name != "b" || got[2].Name != "c" {
+			t.Errorf("sort %d: got %s %s %s, want a b c", by, got[0].Name, got[1].Name, got[2].Name)
+		}
+	}
+}
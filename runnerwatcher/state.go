@@ -3,6 +3,9 @@ package runnerwatcher
 import (
 	"context"
 	"fmt"
+	"gw/dispatcher/debugger/notify"
+	"gw/dispatcher/debugger/redisinfo"
+	"gw/dispatcher/debugger/theme"
 	"math"
 	"strings"
 	"time"
@@ -85,6 +88,23 @@ func puttyTime(t time.Time) string {
 	return t.Format(timePrintFormat)
 }
 
+// Duration in its two largest units, like 45s, 12m5s or 1d4h, so it fits in
+// the LIFE cell however long the runner has been gone. Rounded up to second.
+func compactDuration(d time.Duration) string {
+	sec := int64(math.Ceil(d.Seconds()))
+	switch {
+	case sec < 60:
+		return fmt.Sprintf("%ds", sec)
+	case sec < 3600:
+		return fmt.Sprintf("%dm%ds", sec/60, sec%60)
+	case sec < 86400:
+		return fmt.Sprintf("%dh%dm", sec/3600, sec%3600/60)
+	case sec < 100*86400:
+		return fmt.Sprintf("%dd%dh", sec/86400, sec%86400/3600)
+	}
+	return fmt.Sprintf("%dd", sec/86400)
+}
+
 // The model use to storage runner state and display.
 type state struct {
 	Name      string
@@ -96,8 +116,11 @@ type state struct {
 	Heartbeat *time.Time
 	Pending   *redis.XPending
 
+	// Error of last update, the row keeps values of the update before.
 	err error
-	rdb *redis.Client
+	// Got one update at least, so changes of alive can be told.
+	seen bool
+	rdb  *redis.Client
 }
 
 func newState(name string, rdb *redis.Client) state {
@@ -109,32 +132,43 @@ func (s state) Init() tea.Cmd {
 }
 
 func (s state) View() string {
+	return s.render(false, nil)
+}
 
-	if s.err != nil {
-		return fmt.Sprintf("%s Update error last time %s", s.Name, s.err.Error())
+// Render state row, name of selected runner is highlighted, name of runner failed to update is in error color.
+func (s state) render(selected bool, conns []redisinfo.Client) string {
+	name := nameStyle.Render(s.Name)
+	switch {
+	case selected:
+		name = nameStyle.Inherit(textInverseAndBold).Render(s.Name)
+	case s.err != nil:
+		name = nameStyle.Inherit(errorColor).Render(s.Name)
 	}
 
 	var builder strings.Builder
-	builder.WriteString(nameStyle.Render(s.Name))
+	builder.WriteString(name)
 	builder.WriteString(modelStyle.Render(s.Model))
 
+	// Symbols tell state without color too.
+	ok := theme.Symbol(true) + " "
+	bad := theme.Symbol(false) + " "
 	switch {
 	case s.Alive && s.Heartbeat != nil:
-		text := fmt.Sprintf("ALIVE(%ds)", int(math.Ceil(time.Since(*s.Heartbeat).Seconds())))
-		builder.WriteString(heartbeatStyle.Inherit(okColor).Render(text))
+		text := fmt.Sprintf("ALIVE(%s)", compactDuration(time.Since(*s.Heartbeat)))
+		builder.WriteString(heartbeatStyle.Inherit(okColor).Render(ok + text))
 	case s.Alive && s.Heartbeat == nil:
-		builder.WriteString(heartbeatStyle.Inherit(errorColor).Render("ALIVE(-)"))
+		builder.WriteString(heartbeatStyle.Inherit(errorColor).Render(bad + "ALIVE(-)"))
 	case !s.Alive && s.Heartbeat != nil:
-		text := fmt.Sprintf("DEAD(%ds)", int(math.Ceil(time.Since(*s.Heartbeat).Seconds())))
-		builder.WriteString(heartbeatStyle.Inherit(errorColor).Render(text))
+		text := fmt.Sprintf("DEAD(%s)", compactDuration(time.Since(*s.Heartbeat)))
+		builder.WriteString(heartbeatStyle.Inherit(errorColor).Render(bad + text))
 	case !s.Alive && s.Heartbeat == nil:
-		builder.WriteString(heartbeatStyle.Inherit(errorColor).Render("DEAD(-)"))
+		builder.WriteString(heartbeatStyle.Inherit(errorColor).Render(bad + "DEAD(-)"))
 	}
 
 	if s.Busy {
-		builder.WriteString(stateStyle.Inherit(warningColor).Render("BUSY"))
+		builder.WriteString(stateStyle.Inherit(warningColor).Render(theme.BusySymbol(true) + " BUSY"))
 	} else {
-		builder.WriteString(stateStyle.Inherit(okColor).Render("IDLE"))
+		builder.WriteString(stateStyle.Inherit(okColor).Render(theme.BusySymbol(false) + " IDLE"))
 	}
 
 	if s.Pending != nil {
@@ -150,54 +184,70 @@ func (s state) View() string {
 	builder.WriteString(ctimeStyle.Render(puttyTime(s.Ctime)))
 	builder.WriteString(utimeStyle.Render(puttyTime(s.Utime)))
 
+	if len(conns) == 0 {
+		builder.WriteString(connStyle.Inherit(errorColor).Render(connText(conns)))
+	} else {
+		builder.WriteString(connStyle.Render(connText(conns)))
+	}
+
 	return builder.String()
 }
 
 func (s state) Update(msg tea.Msg) (state, tea.Cmd) {
-	s.err = nil
-
 	switch msg := msg.(type) {
 	case StateUpdateMsg:
-
 		if s.Name != msg.Name {
 			return s, nil
 		}
 
-		if msg.Err != nil {
-			s.err = msg.Err
-			return s, delayRunCommand(1, updateRunnerState(s.Name, s.rdb))
-		}
-
-		s.Model = msg.State["model_id"]
-
-		s.Ctime, s.err = time.ParseInLocation(timeParseFormat, msg.State["ctime"], time.Local)
-		if s.err != nil {
-			return s, nil
-		}
+		cmds := []tea.Cmd{delayRunCommand(1, updateRunnerState(s.Name, s.rdb))}
+		wasAlive, seen := isAlive(&s), s.seen
 
-		s.Utime, s.err = time.ParseInLocation(timeParseFormat, msg.State["utime"], time.Local)
-		if s.err != nil {
-			return s, nil
+		// Tell error once, not on every retry.
+		err := s.apply(&msg)
+		if err != nil && s.err == nil {
+			cmds = append(cmds, notify.Err(notifySource, fmt.Errorf("update %s: %w", s.Name, err)))
 		}
-
-		if msg.State["busy"] == "0" {
-			s.Busy = false
-		} else {
-			s.Busy = true
+		s.err = err
+		if err != nil {
+			return s, tea.Batch(cmds...)
 		}
 
-		if msg.State["is_alive"] == "0" {
-			s.Alive = false
-		} else {
-			s.Alive = true
+		s.seen = true
+		switch {
+		case seen && wasAlive && !isAlive(&s):
+			cmds = append(cmds, notify.Send(notifySource, notify.Warning, fmt.Sprintf("Runner %s died", s.Name)))
+		case seen && !wasAlive && isAlive(&s):
+			cmds = append(cmds, notify.Send(notifySource, notify.Info, fmt.Sprintf("Runner %s is alive", s.Name)))
 		}
-
-		s.Pending = msg.Pending
-		s.Heartbeat = msg.Heartbeat
-
-		return s, delayRunCommand(1, updateRunnerState(s.Name, s.rdb))
+		return s, tea.Batch(cmds...)
 
 	default:
 		return s, nil
 	}
 }
+
+// Set fields from update.
+func (s *state) apply(msg *StateUpdateMsg) error {
+	if msg.Err != nil {
+		return msg.Err
+	}
+
+	ctime, err := time.ParseInLocation(timeParseFormat, msg.State["ctime"], time.Local)
+	if err != nil {
+		return err
+	}
+	utime, err := time.ParseInLocation(timeParseFormat, msg.State["utime"], time.Local)
+	if err != nil {
+		return err
+	}
+
+	s.Model = msg.State["model_id"]
+	s.Ctime = ctime
+	s.Utime = utime
+	s.Busy = msg.State["busy"] != "0"
+	s.Alive = msg.State["is_alive"] != "0"
+	s.Pending = msg.Pending
+	s.Heartbeat = msg.Heartbeat
+	return nil
+}
@@ -0,0 +1,28 @@
+package runnerwatcher
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCompactDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0s"},
+		{1500 * time.Millisecond, "2s"},
+		{59 * time.Second, "59s"},
+		{time.Minute, "1m0s"},
+		{59*time.Minute + 59*time.Second, "59m59s"},
+		{27*time.Hour + 50*time.Minute, "1d3h"},
+		{100000 * time.Second, "1d3h"},
+		{99*24*time.Hour + 23*time.Hour, "99d23h"},
+		{400 * 24 * time.Hour, "400d"},
+	}
+	for _, tt := range tests {
+		if got := compactDuration(tt.d); got != tt.want {
+			t.Errorf("compactDuration(%s) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
@@ -3,13 +3,16 @@ package runnerwatcher
 import (
 	"context"
 	"fmt"
+	"gw/dispatcher/debugger/listview"
 	"gw/dispatcher/debugger/msgs"
+	"gw/dispatcher/debugger/notify"
+	"gw/dispatcher/debugger/redisinfo"
 	"gw/dispatcher/debugger/style"
 	"gw/dispatcher/debugger/theme"
-	"sort"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/redis/go-redis/v9"
@@ -17,25 +20,42 @@ import (
 
 // Define runner table column width and align.
 var (
-	nameStyle      = style.W().S
-	modelStyle     = style.W().L
-	heartbeatStyle = style.W().M.Align(lipgloss.Center)
+	nameStyle  = style.W().S
+	modelStyle = style.W().L
+	// Room for state symbol before text and longest duration, ALIVE(59m59s).
+	heartbeatStyle = style.W().M.Width(15).Align(lipgloss.Center)
 	stateStyle     = style.W().S.Align(lipgloss.Center)
 	pendingStyle   = style.W().S.Align(lipgloss.Center)
 	ctimeStyle     = style.W().L.Align(lipgloss.Center)
 	utimeStyle     = style.W().L.Align(lipgloss.Center)
+	connStyle      = style.W().XL.PaddingLeft(1)
 	statusStyle    = style.W().S.Align(lipgloss.Center)
 )
 
-// Define column color modifier.
+// Define column color modifier, rebuilt on theme change.
 var (
-	okColor            = lipgloss.NewStyle().Background(theme.G().Success).Foreground(theme.G().TextDark)
-	errorColor         = lipgloss.NewStyle().Background(theme.G().Error).Foreground(theme.G().TextDark)
-	warningColor       = lipgloss.NewStyle().Background(theme.G().Warning).Foreground(theme.G().TextDark)
-	textInverse        = lipgloss.NewStyle().Background(theme.G().BackgroundInverse).Foreground(theme.G().TextDark)
-	textInverseAndBold = textInverse.Bold(true)
+	okColor            lipgloss.Style
+	errorColor         lipgloss.Style
+	warningColor       lipgloss.Style
+	textInverse        lipgloss.Style
+	textInverseAndBold lipgloss.Style
+	confirmText        lipgloss.Style
 )
 
+func init() {
+	theme.OnChange(func(t theme.Theme) {
+		okColor = t.On(t.Success)
+		errorColor = t.On(t.Error)
+		warningColor = t.On(t.Warning)
+		textInverse = t.On(t.BackgroundInverse)
+		textInverseAndBold = textInverse.Bold(true)
+		confirmText = warningColor.Padding(0, 1)
+	})
+}
+
+// Source of notifications from this tab.
+const notifySource = "runner"
+
 // Use to update runner keys.
 type UpdateRunnerNamesMsg struct {
 	Names []string
@@ -67,12 +87,15 @@ func delayRunCommand(delay_s int, cmd tea.Cmd) tea.Cmd {
 	}
 }
 
-func New() Model {
+// Addrs maps runner name to its address, use to find connection of runner without client name.
+func New(addrs map[string]string) Model {
 	return Model{
-		states: make(map[string]state),
-		height: 0,
-		width:  0,
-		csr:    0,
+		bindings: newKeyMap(),
+		states:   make(map[string]state),
+		addrs:    addrs,
+		height:   0,
+		width:    0,
+		list:     listview.New(),
 
 		rdb: nil,
 		err: nil,
@@ -80,16 +103,32 @@ func New() Model {
 }
 
 type Model struct {
+	bindings keyMap
+
 	states      map[string]state
 	streamState msgs.StreamUpdateMsg
 
 	height int
 	width  int
 
-	csr int
+	// Selected row and viewport of runner table.
+	list listview.Model
+	// Name of selected runner, cursor follows it when rows reorder.
+	selected string
 
-	rdb *redis.Client
+	rdb      *redis.Client
+	readonly bool
+	// Error of last runner list update, runners listed before are kept.
 	err error
+
+	decommission decommission
+	notice       string
+
+	sortBy sortColumn
+
+	clients   []redisinfo.Client
+	clientErr error
+	addrs     map[string]string
 }
 
 func (m Model) Init() tea.Cmd {
@@ -97,14 +136,13 @@ func (m Model) Init() tea.Cmd {
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	m.err = nil
-
 	switch msg := msg.(type) {
 
 	case msgs.RedisStateMsg:
 		m.rdb = msg.Client
+		m.readonly = msg.ReadOnly
 		if m.rdb != nil {
-			return m, updateRunneNames(m.rdb)
+			return m, tea.Batch(updateRunneNames(m.rdb), updateClientList(m.rdb))
 		} else {
 			return m, nil
 		}
@@ -113,24 +151,73 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.streamState = msg
 		return m, nil
 
-	case tea.KeyMsg:
-		switch msg.String() {
-		case "up":
-			if m.csr > 0 {
-				m.csr--
-			}
-		case "down":
-			if m.csr < len(m.states)-1 {
-				m.csr++
-			}
+	case ClientListMsg:
+		next := delayRunCommand(clientListPeriod, updateClientList(m.rdb))
+		failed := msg.Err != nil && m.clientErr == nil
+		m.clientErr = msg.Err
+		if msg.Err == nil {
+			m.clients = msg.Clients
 		}
+		if failed {
+			return m.scroll(), tea.Batch(next, notify.Err(notifySource, fmt.Errorf("list clients: %w", msg.Err)))
+		}
+		return m.scroll(), next
+
+	case decommissionLoadedMsg:
+		var c tea.Cmd
+		m.decommission, c = m.decommission.Update(msg, m.rdb)
+		return m, c
+
+	case decommissionDoneMsg:
+		m.decommission.stage = decommissionClosed
+		if msg.Err != nil {
+			m.notice = fmt.Sprintf("Decommission %s failed after moving %d entries: %s", msg.Name, msg.Moved, msg.Err.Error())
+			return m, notify.Send(notifySource, notify.Error, m.notice)
+		}
+		m.notice = fmt.Sprintf("Decommissioned %s, %d entries moved, %d keys deleted", msg.Name, msg.Moved, msg.Deleted)
 		return m, nil
 
+	case sortMsg:
+		m.sortBy = sortColumn(msg)
+		return m.scroll(), nil
+
+	case tea.MouseMsg:
+		if m.decommission.stage != decommissionClosed {
+			return m, nil
+		}
+		return m.handleMouse(msg)
+
+	case openDecommissionMsg:
+		if m.decommission.stage != decommissionClosed {
+			return m, nil
+		}
+		return m.openDecommission()
+
+	case tea.KeyMsg:
+		if m.decommission.stage != decommissionClosed {
+			var c tea.Cmd
+			m.decommission, c = m.decommission.Update(msg, m.rdb)
+			return m, c
+		}
+
+		m.notice = ""
+		if key.Matches(msg, m.bindings.Decommission) {
+			return m.openDecommission()
+		}
+		m.list, _ = m.list.Update(msg)
+		return m.pick().scroll(), nil
+
 	case UpdateRunnerNamesMsg:
 		if msg.Err != nil {
+			// Tell error once, not on every retry.
+			next := delayRunCommand(1, updateRunneNames(m.rdb))
+			if m.err == nil {
+				next = tea.Batch(next, notify.Err(notifySource, fmt.Errorf("list runners: %w", msg.Err)))
+			}
 			m.err = msg.Err
-			return m, delayRunCommand(1, updateRunneNames(m.rdb))
+			return m, next
 		}
+		m.err = nil
 
 		cmd := make([]tea.Cmd, 0)
 		newStates := make(map[string]state)
@@ -146,13 +233,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 		m.states = newStates
+		m = m.scroll()
 		cmd = append(cmd, delayRunCommand(1, updateRunneNames(m.rdb)))
 		return m, tea.Batch(cmd...)
 
 	case tea.WindowSizeMsg:
 		m.height = msg.Height
 		m.width = msg.Width
-		return m, nil
+		return m.scroll(), nil
 
 	case StateUpdateMsg:
 		state, ok := m.states[msg.Name]
@@ -161,7 +249,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		s, cmd := state.Update(msg)
 		m.states[msg.Name] = s
-		return m, cmd
+		return m.scroll(), cmd
 
 	default:
 		return m, nil
@@ -172,28 +260,152 @@ func (m Model) View() string {
 	if m.rdb == nil {
 		return "Redis disconnected."
 	}
+	if m.decommission.stage != decommissionClosed {
+		return m.decommission.View()
+	}
 
 	var builder strings.Builder
-	builder.WriteString(stateTableHeader(m.width) + "\n")
+	builder.WriteString(stateTableHeader(m.width, m.sortBy) + "\n")
+
+	orderedStates := m.orderedStates()
+
+	start, end := m.list.Range()
+	for pos := start; pos < end; pos++ {
+		conns := runnerClients(orderedStates[pos].Name, m.clients, m.addrs)
+		builder.WriteString(orderedStates[pos].render(pos == m.list.Cursor(), conns) + "\n")
+	}
+	// Blank rows keep notice and detail in place.
+	builder.WriteString(strings.Repeat("\n", max(0, m.pageSize()-(end-start))))
+
+	notice := m.notice
+	if notice == "" && m.err != nil {
+		notice = errorColor.Render("Runner list is outdated: " + m.err.Error())
+	}
+	indicator := m.list.Indicator()
+	space := max(1, m.width-lipgloss.Width(notice)-lipgloss.Width(indicator))
+	builder.WriteString(notice + strings.Repeat(" ", space) + indicator + "\n")
+
+	if m.list.Cursor() < len(orderedStates) {
+		name := orderedStates[m.list.Cursor()].Name
+		builder.WriteString(connDetail(name, runnerClients(name, m.clients, m.addrs), m.clientErr))
+	}
+
+	return builder.String()
+}
 
+// States in display order.
+func (m Model) orderedStates() []state {
 	orderedStates := make([]state, 0)
 	for _, s := range m.states {
 		orderedStates = append(orderedStates, s)
 	}
+	return sortState(orderedStates, m.sortBy)
+}
+
+func (m Model) pageSize() int {
+	const headerHeight = 1
+	const noticeHeight = 1
+	return max(1, m.height-headerHeight-noticeHeight-m.detailHeight())
+}
+
+// Height of connection detail of selected runner.
+func (m Model) detailHeight() int {
+	const titleHeight = 1
+	ordered := m.orderedStates()
+	if m.list.Cursor() >= len(ordered) {
+		return 0
+	}
+	return titleHeight + max(1, len(runnerClients(ordered[m.list.Cursor()].Name, m.clients, m.addrs)))
+}
+
+// Fit list to runner count and page, page shrinks with detail of selected runner.
+// Cursor stays on selected runner when rows reorder.
+func (m Model) scroll() Model {
+	ordered := m.orderedStates()
+	m.list = m.list.SetCount(len(ordered))
+	for i := range ordered {
+		if ordered[i].Name == m.selected {
+			m.list = m.list.SetCursor(i)
+			break
+		}
+	}
+	m.list = m.list.SetHeight(m.pageSize())
+	return m.pick()
+}
 
-	orderedStates = sortState(orderedStates)
+// Remember runner under cursor after cursor moves.
+func (m Model) pick() Model {
+	ordered := m.orderedStates()
+	if m.list.Cursor() < len(ordered) {
+		m.selected = ordered[m.list.Cursor()].Name
+	}
+	return m
+}
 
+// Wheel moves cursor, click selects row or sorts by column header.
+func (m Model) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 	const headerHeight = 1
-	pageSize := m.height - headerHeight
-	pos := m.csr
-	end := min(pos+pageSize, len(orderedStates))
 
-	for pos < end {
-		builder.WriteString(orderedStates[pos].View() + "\n")
-		pos++
+	if list, ok := m.list.Update(msg); ok {
+		m.list = list
+		return m.pick().scroll(), nil
 	}
 
-	return builder.String()
+	switch {
+	case msg.Button != tea.MouseButtonLeft || msg.Action != tea.MouseActionPress:
+		return m, nil
+	case msg.Y < headerHeight:
+		x := 0
+		for _, c := range columns() {
+			x += c.style.GetWidth()
+			if msg.X < x {
+				if c.sort != sortNone {
+					m.sortBy = c.sort
+				}
+				break
+			}
+		}
+	default:
+		if list, ok := m.list.Click(msg.Y - headerHeight); ok {
+			m.notice = ""
+			m.list = list
+			m = m.pick()
+		}
+	}
+	return m.scroll(), nil
+}
+
+// Open decommission dialog for selected runner, only dead runner can be decommissioned.
+func (m Model) openDecommission() (tea.Model, tea.Cmd) {
+	ordered := m.orderedStates()
+	if len(ordered) == 0 {
+		return m, nil
+	}
+	if m.readonly {
+		m.notice = "Decommission is disabled in read-only mode, restart without -readonly or use a non-production profile."
+		return m, nil
+	}
+
+	selected := ordered[m.list.Cursor()]
+	if isAlive(&selected) {
+		m.notice = fmt.Sprintf("Runner %s is alive, only dead runner can be decommissioned.", selected.Name)
+		return m, nil
+	}
+
+	alive := make([]string, 0)
+	for i := range ordered {
+		if isAlive(&ordered[i]) {
+			alive = append(alive, ordered[i].Name)
+		}
+	}
+
+	m.decommission = newDecommission(selected.Name, alive, m.bindings)
+	return m, loadRunnerData(m.rdb, selected.Name)
+}
+
+// Decommission dialog needs every key press.
+func (m Model) CapturingInput() bool {
+	return m.decommission.stage != decommissionClosed
 }
 
 func (m Model) StatusBarView() string {
@@ -247,49 +459,6 @@ func countIf(states map[string]state, cond func(*state) bool) int {
 	return cnt
 }
 
-func stateTableHeader(width int) string {
-	var builder strings.Builder
-
-	builder.WriteString(nameStyle.Inherit(textInverseAndBold).Render("NAME"))
-	builder.WriteString(modelStyle.Inherit(textInverseAndBold).Render("MODEL"))
-	builder.WriteString(heartbeatStyle.Inherit(textInverseAndBold).Render("LIFE"))
-
-	builder.WriteString(stateStyle.Inherit(textInverseAndBold).Render("BUSY"))
-	builder.WriteString(pendingStyle.Inherit(textInverseAndBold).Render("PEND"))
-
-	builder.WriteString(ctimeStyle.Inherit(textInverseAndBold).Render("CTIME"))
-	builder.WriteString(utimeStyle.Inherit(textInverseAndBold).Render("UTIME"))
-
-	// Fill the rest of this line.
-	return textInverse.Width(width).Render(builder.String())
-}
-
 func isAlive(m *state) bool {
 	return m.Alive && m.Heartbeat != nil
 }
-
-func sortState(states []state) []state {
-	sort.SliceStable(states, func(i, j int) bool {
-		if isAlive(&states[i]) && isAlive(&states[j]) {
-			if states[i].Busy == states[j].Busy {
-				return states[i].Ctime.Unix() > states[j].Ctime.Unix()
-			}
-			if states[i].Busy {
-				return true
-			} else {
-				return false
-			}
-		}
-
-		if isAlive(&states[i]) {
-			return true
-		}
-		if isAlive(&states[j]) {
-			return false
-		}
-
-		return states[i].Ctime.Unix() > states[j].Ctime.Unix()
-	})
-
-	return states
-}
@@ -0,0 +1,390 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"gw/dispatcher/debugger/keymap"
+	"gw/dispatcher/debugger/msgs"
+	"gw/dispatcher/debugger/notify"
+	"gw/dispatcher/debugger/redisinfo"
+	"gw/dispatcher/debugger/style"
+	"gw/dispatcher/debugger/theme"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	fieldStyle  = style.W().L
+	valueStyle  = style.W().M
+	addrStyle   = style.W().L
+	nameStyle   = style.W().M
+	numberStyle = style.W().S.Align(lipgloss.Right)
+	cmdStyle    = style.W().M.PaddingLeft(1)
+	statusStyle = style.W().M.Padding(0, 1)
+)
+
+// Rebuilt on theme change.
+var sectionStyle lipgloss.Style
+
+func init() {
+	theme.OnChange(func(t theme.Theme) {
+		sectionStyle = t.On(t.BackgroundInverse).Bold(true)
+	})
+}
+
+// Refresh period in second.
+const refreshPeriod = 2
+
+// Source of notifications from this tab.
+const notifySource = "server"
+
+// How many slow log entries are read.
+const slowlogSize = 10
+
+// Fields shown of each INFO section.
+var infoFields = []struct {
+	Section string
+	Fields  []string
+}{
+	{"memory", []string{"used_memory_human", "used_memory_peak_human", "maxmemory_human", "maxmemory_policy", "mem_fragmentation_ratio"}},
+	{"clients", []string{"connected_clients", "blocked_clients", "maxclients"}},
+	{"stats", []string{"instantaneous_ops_per_sec", "instantaneous_input_kbps", "instantaneous_output_kbps", "total_commands_processed", "rejected_connections", "evicted_keys", "expired_keys"}},
+	{"replication", []string{"role", "connected_slaves", "master_host", "master_link_status"}},
+	{"server", []string{"redis_version", "uptime_in_days"}},
+}
+
+// One event in LATENCY LATEST.
+type latencyEvent struct {
+	Event  string
+	Time   time.Time
+	Latest time.Duration
+	Max    time.Duration
+}
+
+// Use to deliver server state, each section has its own error because managed
+// Redis often disables some of SLOWLOG, CLIENT LIST and LATENCY.
+type ServerUpdateMsg struct {
+	Info       redisinfo.Info
+	InfoErr    error
+	Slowlog    []redis.SlowLog
+	SlowlogErr error
+	Clients    []redisinfo.Client
+	ClientsErr error
+	Latency    []latencyEvent
+	LatencyErr error
+}
+
+type keyMap struct {
+	keymap.Common
+}
+
+func newKeyMap() keyMap {
+	return keyMap{Common: keymap.NewCommon()}
+}
+
+type Model struct {
+	bindings keyMap
+	rdb      *redis.Client
+	// Last good data of each section and error of its last read.
+	state ServerUpdateMsg
+
+	height int
+	// First client shown and its id, it follows the client when list reorders.
+	csr      int
+	selected string
+}
+
+func New() Model {
+	return Model{bindings: newKeyMap()}
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case msgs.RedisStateMsg:
+		m.rdb = msg.Client
+		if m.rdb == nil {
+			return m, nil
+		}
+		return m, readServerState(m.rdb)
+
+	case ServerUpdateMsg:
+		cmds := []tea.Cmd{delayRunCommand(refreshPeriod, readServerState(m.rdb))}
+		// Tell error of each section once, not on every refresh.
+		for _, s := range []struct {
+			command string
+			last    *error
+			now     error
+		}{
+			{"INFO", &m.state.InfoErr, msg.InfoErr},
+			{"SLOWLOG", &m.state.SlowlogErr, msg.SlowlogErr},
+			{"CLIENT LIST", &m.state.ClientsErr, msg.ClientsErr},
+			{"LATENCY LATEST", &m.state.LatencyErr, msg.LatencyErr},
+		} {
+			if s.now != nil && *s.last == nil {
+				cmds = append(cmds, notify.Err(notifySource, fmt.Errorf("%s: %w", s.command, s.now)))
+			}
+			*s.last = s.now
+		}
+
+		// Failed section keeps what it showed.
+		if msg.InfoErr == nil {
+			m.state.Info = msg.Info
+		}
+		if msg.SlowlogErr == nil {
+			m.state.Slowlog = msg.Slowlog
+		}
+		if msg.LatencyErr == nil {
+			m.state.Latency = msg.Latency
+		}
+		if msg.ClientsErr == nil {
+			m.state.Clients = msg.Clients
+			m = m.follow()
+		}
+		return m, tea.Batch(cmds...)
+
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+		return m, nil
+
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, m.bindings.Up):
+			if m.csr > 0 {
+				m.csr--
+			}
+		case key.Matches(msg, m.bindings.Down):
+			if m.csr < len(m.state.Clients)-1 {
+				m.csr++
+			}
+		}
+		if m.csr < len(m.state.Clients) {
+			m.selected = m.state.Clients[m.csr].ID
+		}
+		return m, nil
+	}
+
+	return m, nil
+}
+
+// Keep selected client first in view after client list reorders.
+func (m Model) follow() Model {
+	for i := range m.state.Clients {
+		if m.state.Clients[i].ID == m.selected {
+			m.csr = i
+			return m
+		}
+	}
+	m.csr = max(0, min(m.csr, len(m.state.Clients)-1))
+	if m.csr < len(m.state.Clients) {
+		m.selected = m.state.Clients[m.csr].ID
+	}
+	return m
+}
+
+// Up and down scroll client list.
+func (m Model) Help() help.KeyMap {
+	k := m.bindings
+	return keymap.Help{Short: []key.Binding{k.Up, k.Down}, Full: [][]key.Binding{{k.Up, k.Down}}}
+}
+
+// Run command after a delay, unit is seconds.
+func delayRunCommand(sec time.Duration, cmd tea.Cmd) tea.Cmd {
+	return func() tea.Msg {
+		time.Sleep(sec * time.Second)
+		return cmd()
+	}
+}
+
+// Command read INFO, SLOWLOG, CLIENT LIST and LATENCY LATEST, one failing
+// doesn't stop the others.
+func readServerState(rdb *redis.Client) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+		result := ServerUpdateMsg{}
+
+		info, err := rdb.Info(ctx, "all").Result()
+		result.InfoErr = err
+		if err == nil {
+			result.Info = redisinfo.ParseInfo(info)
+		}
+
+		result.Slowlog, result.SlowlogErr = rdb.SlowLogGet(ctx, slowlogSize).Result()
+
+		clients, err := rdb.ClientList(ctx).Result()
+		result.ClientsErr = err
+		if err == nil {
+			result.Clients = redisinfo.ParseClientList(clients)
+			// Most recently active first.
+			sort.SliceStable(result.Clients, func(i, j int) bool {
+				a, b := &result.Clients[i], &result.Clients[j]
+				if a.Idle != b.Idle {
+					return a.Idle < b.Idle
+				}
+				return a.ID < b.ID
+			})
+		}
+
+		result.Latency, result.LatencyErr = readLatency(ctx, rdb)
+		return result
+	}
+}
+
+func readLatency(ctx context.Context, rdb *redis.Client) ([]latencyEvent, error) {
+	reply, err := rdb.Do(ctx, "latency", "latest").Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]latencyEvent, 0, len(reply))
+	for _, item := range reply {
+		fields, ok := item.([]interface{})
+		if !ok || len(fields) < 4 {
+			continue
+		}
+		event := latencyEvent{Event: fmt.Sprint(fields[0])}
+		if ts, ok := fields[1].(int64); ok {
+			event.Time = time.Unix(ts, 0)
+		}
+		if ms, ok := fields[2].(int64); ok {
+			event.Latest = time.Duration(ms) * time.Millisecond
+		}
+		if ms, ok := fields[3].(int64); ok {
+			event.Max = time.Duration(ms) * time.Millisecond
+		}
+		result = append(result, event)
+	}
+	return result, nil
+}
+
+func (m Model) View() string {
+	if m.rdb == nil {
+		return "Redis disconnected."
+	}
+	if m.state.Info == nil {
+		if m.state.InfoErr != nil {
+			return m.state.InfoErr.Error()
+		}
+		return "Loading..."
+	}
+
+	infoBlocks := make([]string, 0)
+	for _, s := range infoFields {
+		infoBlocks = append(infoBlocks, infoSection(m.state.Info, s.Section, s.Fields))
+	}
+	infoBlocks = append(infoBlocks, keyspaceSection(m.state.Info))
+
+	left := lipgloss.JoinVertical(lipgloss.Left, infoBlocks...)
+	right := lipgloss.JoinVertical(lipgloss.Left,
+		slowlogSection(m.state.Slowlog),
+		"",
+		latencySection(m.state.Latency),
+	)
+
+	top := lipgloss.JoinHorizontal(lipgloss.Top, left, "  ", right)
+	if errs := m.sectionErrors(); errs != "" {
+		top = lipgloss.JoinVertical(lipgloss.Left, errs, top)
+	}
+
+	height := m.height - lipgloss.Height(top) - 1
+	return lipgloss.JoinVertical(lipgloss.Left, top, "", clientSection(m.state.Clients, m.csr, height))
+}
+
+// Errors of sections that failed last read, one per line, their data is outdated.
+func (m Model) sectionErrors() string {
+	lines := make([]string, 0)
+	for _, s := range []struct {
+		command string
+		err     error
+	}{
+		{"INFO", m.state.InfoErr},
+		{"SLOWLOG", m.state.SlowlogErr},
+		{"CLIENT LIST", m.state.ClientsErr},
+		{"LATENCY LATEST", m.state.LatencyErr},
+	} {
+		if s.err != nil {
+			lines = append(lines, s.command+": "+s.err.Error())
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m Model) StatusBarView() string {
+	return statusStyle.Render(fmt.Sprintf("%s ops/s", m.state.Info.Get("stats", "instantaneous_ops_per_sec")))
+}
+
+func infoSection(info redisinfo.Info, section string, fields []string) string {
+	rows := []string{sectionStyle.Render(fieldStyle.Render(strings.ToUpper(section)) + valueStyle.Render(""))}
+	for _, f := range fields {
+		v := info.Get(section, f)
+		if v == "" {
+			continue
+		}
+		rows = append(rows, fieldStyle.Render(f)+valueStyle.Render(v))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+}
+
+func keyspaceSection(info redisinfo.Info) string {
+	rows := []string{sectionStyle.Render(fieldStyle.Render("KEYSPACE") + valueStyle.Render(""))}
+
+	dbs := make([]string, 0)
+	for db := range info["keyspace"] {
+		dbs = append(dbs, db)
+	}
+	sort.Strings(dbs)
+	for _, db := range dbs {
+		rows = append(rows, fieldStyle.Render(db)+info["keyspace"][db])
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+}
+
+func slowlogSection(entries []redis.SlowLog) string {
+	rows := []string{sectionStyle.Render(numberStyle.Render("ID") + valueStyle.Render(" DURATION") + "COMMAND")}
+	for _, e := range entries {
+		rows = append(rows, numberStyle.Render(fmt.Sprintf("%d", e.ID))+
+			valueStyle.Render(" "+e.Duration.String())+
+			ansi.Truncate(strings.Join(e.Args, " "), 60, "..."))
+	}
+	if len(entries) == 0 {
+		rows = append(rows, "No slow command.")
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+}
+
+func latencySection(events []latencyEvent) string {
+	rows := []string{sectionStyle.Render(fieldStyle.Render("LATENCY EVENT") + valueStyle.Render("LATEST") + valueStyle.Render("MAX"))}
+	for _, e := range events {
+		rows = append(rows, fieldStyle.Render(e.Event)+valueStyle.Render(e.Latest.String())+valueStyle.Render(e.Max.String()))
+	}
+	if len(events) == 0 {
+		rows = append(rows, "No latency event, latency-monitor-threshold may be 0.")
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+}
+
+func clientSection(clients []redisinfo.Client, csr, height int) string {
+	rows := []string{sectionStyle.Render(
+		addrStyle.Render("CLIENT ADDR") + nameStyle.Render("NAME") + numberStyle.Render("DB") +
+			numberStyle.Render("AGE") + numberStyle.Render("IDLE") + cmdStyle.Render("CMD"))}
+
+	pageSize := max(1, height-1)
+	end := min(csr+pageSize, len(clients))
+	for i := csr; i < end; i++ {
+		c := &clients[i]
+		rows = append(rows, addrStyle.Render(c.Addr)+nameStyle.Render(c.Name)+numberStyle.Render(c.DB)+
+			numberStyle.Render(c.Age.String())+numberStyle.Render(c.Idle.String())+cmdStyle.Render(c.Cmd))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, rows...)
+}
@@ -0,0 +1,375 @@
+package stuck
+
+import (
+	"context"
+	"fmt"
+	"gw/dispatcher/debugger/keymap"
+	"gw/dispatcher/debugger/listview"
+	"gw/dispatcher/debugger/msgs"
+	"gw/dispatcher/debugger/notify"
+	"gw/dispatcher/debugger/requeue"
+	"gw/dispatcher/debugger/style"
+	"gw/dispatcher/debugger/theme"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/redis/go-redis/v9"
+)
+
+// Define stuck table column width and align.
+var (
+	streamStyle   = style.W().XL
+	idStyle       = style.W().L
+	consumerStyle = style.W().L
+	idleStyle     = style.W().M.Align(lipgloss.Right)
+	deliverStyle  = style.W().S.Align(lipgloss.Right)
+	statusStyle   = style.W().M.Padding(0, 1)
+)
+
+// Rebuilt on theme change.
+var (
+	textInverse        lipgloss.Style
+	textInverseAndBold lipgloss.Style
+	selectedRow        lipgloss.Style
+)
+
+func init() {
+	theme.OnChange(func(t theme.Theme) {
+		textInverse = t.On(t.BackgroundInverse)
+		textInverseAndBold = textInverse.Bold(true)
+		selectedRow = t.On(t.PanelLight)
+	})
+}
+
+const (
+	// Pattern of all streams, both global queues and runner streams.
+	streamPattern = "*::stream::gw"
+
+	// Pending entries asked for on each XPENDING call.
+	pendingPageSize = 1000
+)
+
+// Table header above entries, notice and position indicator below.
+const headerHeight, noticeHeight = 1, 1
+
+// Scan pending entries period in second.
+const scanPeriod = 5
+
+// Source of notifications from this tab.
+const notifySource = "stuck"
+
+// How many requeue records are kept and shown.
+const maxHistory = 5
+
+// Thresholds to decide whether a pending entry is stuck.
+type Config struct {
+	// Entry idle longer than this is stuck.
+	Idle time.Duration
+
+	// Entry delivered more times than this is stuck.
+	MaxDeliveries int64
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Idle:          5 * time.Minute,
+		MaxDeliveries: 3,
+	}
+}
+
+type keyMap struct {
+	Requeue key.Binding
+}
+
+func newKeyMap() keyMap {
+	return keyMap{Requeue: keymap.New("stuck.requeue", "requeue entry", "r")}
+}
+
+type Model struct {
+	bindings keyMap
+	rdb      *redis.Client
+	readonly bool
+	cfg      Config
+
+	entries []msgs.StuckEntry
+	err     error
+
+	height int
+	width  int
+	// Selected row and viewport of entry table.
+	list listview.Model
+
+	requeue requeue.Model
+	history []string
+	notice  string
+}
+
+func New(cfg Config) Model {
+	return Model{bindings: newKeyMap(), cfg: cfg, list: listview.New()}
+}
+
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case msgs.RedisStateMsg:
+		m.rdb = msg.Client
+		m.readonly = msg.ReadOnly
+		if m.rdb == nil {
+			return m, nil
+		}
+		return m, scanStuck(m.rdb, m.cfg)
+
+	case msgs.StuckUpdateMsg:
+		next := delayRunCommand(scanPeriod, scanStuck(m.rdb, m.cfg))
+		// Tell error once, not on every scan.
+		failed := msg.Err != nil && m.err == nil
+		m.err = msg.Err
+		if failed {
+			return m, tea.Batch(next, notify.Err(notifySource, fmt.Errorf("scan pending entries: %w", msg.Err)))
+		}
+		if msg.Err != nil {
+			return m, next
+		}
+		m.entries = msg.Entries
+		return m.scroll(), next
+
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+		m.width = msg.Width
+		return m.scroll(), nil
+
+	case tea.MouseMsg:
+		if m.requeue.Open() {
+			return m, nil
+		}
+		if list, ok := m.list.Update(msg); ok {
+			m.list = list
+			return m, nil
+		}
+		if msg.Action == tea.MouseActionPress && msg.Button == tea.MouseButtonLeft {
+			m.list, _ = m.list.Click(msg.Y - headerHeight)
+		}
+		return m, nil
+
+	case requeue.LoadedMsg:
+		var c tea.Cmd
+		m.requeue, c = m.requeue.Update(msg, m.rdb)
+		if msg.Err != nil {
+			c = tea.Batch(c, notify.Err(notifySource, fmt.Errorf("load %s %s: %w", msg.Entry.Stream, msg.Entry.ID, msg.Err)))
+		}
+		return m, c
+
+	case requeue.DoneMsg:
+		m.history = append(m.history, msg.Describe())
+		if len(m.history) > maxHistory {
+			m.history = m.history[len(m.history)-maxHistory:]
+		}
+		m.requeue = m.requeue.Close()
+		if msg.Err != nil {
+			return m.scroll(), notify.Err(notifySource, fmt.Errorf("requeue %s %s: %w", msg.Entry.Stream, msg.Entry.ID, msg.Err))
+		}
+		return m.scroll(), nil
+
+	case tea.KeyMsg:
+		if m.requeue.Open() {
+			var c tea.Cmd
+			m.requeue, c = m.requeue.Update(msg, m.rdb)
+			return m, c
+		}
+
+		m.notice = ""
+		switch {
+		case key.Matches(msg, m.bindings.Requeue):
+			if len(m.entries) == 0 {
+				return m, nil
+			}
+			if m.readonly {
+				m.notice = "Requeue is disabled in read-only mode, restart without -readonly or use a non-production profile."
+				return m, nil
+			}
+			e := m.entries[m.list.Cursor()]
+			entry := requeue.Entry{Stream: e.Stream, Group: e.Group, ID: e.ID}
+			m.requeue = requeue.New(entry)
+			return m, requeue.Load(m.rdb, entry)
+		}
+		m.list, _ = m.list.Update(msg)
+		return m, nil
+	}
+
+	return m, nil
+}
+
+func (m Model) View() string {
+	if m.rdb == nil {
+		return "Redis disconnected."
+	}
+	if m.requeue.Open() {
+		return m.requeue.View()
+	}
+
+	var builder strings.Builder
+	builder.WriteString(tableHeader() + "\n")
+
+	start, end := m.list.Range()
+	rows := end - start
+	if len(m.entries) == 0 {
+		builder.WriteString(fmt.Sprintf("No entry idle over %s or delivered over %d times.\n",
+			m.cfg.Idle, m.cfg.MaxDeliveries))
+		rows = 1
+	}
+
+	for i := start; i < end; i++ {
+		row := entryRow(&m.entries[i])
+		if i == m.list.Cursor() {
+			row = selectedRow.Render(row)
+		}
+		builder.WriteString(row + "\n")
+	}
+	// Blank rows keep notice and history in place.
+	builder.WriteString(strings.Repeat("\n", max(0, m.pageSize()-rows)))
+
+	notice := m.notice
+	if notice == "" && m.err != nil {
+		notice = "Last scan failed, list is outdated, see error log."
+	}
+	indicator := m.list.Indicator()
+	space := max(1, m.width-lipgloss.Width(notice)-lipgloss.Width(indicator))
+	builder.WriteString(notice + strings.Repeat(" ", space) + indicator + "\n")
+
+	for _, line := range m.history {
+		builder.WriteString(line + "\n")
+	}
+	return builder.String()
+}
+
+// Editing requeue form needs every key, including those app use to switch tab.
+func (m Model) CapturingInput() bool {
+	return m.requeue.Open()
+}
+
+func (m Model) Help() help.KeyMap {
+	if m.requeue.Open() {
+		return m.requeue.Help()
+	}
+	return keymap.Help{
+		Short: []key.Binding{m.bindings.Requeue},
+		Full:  [][]key.Binding{m.list.Bindings(), {m.bindings.Requeue}},
+	}
+}
+
+func (m Model) pageSize() int {
+	return max(1, m.height-headerHeight-noticeHeight-len(m.history))
+}
+
+// Fit list to entry count and page, page shrinks as requeue history grows.
+func (m Model) scroll() Model {
+	m.list = m.list.SetCount(len(m.entries)).SetHeight(m.pageSize())
+	return m
+}
+
+func (m Model) StatusBarView() string {
+	return statusStyle.Render(fmt.Sprintf("%d stuck", len(m.entries)))
+}
+
+func tableHeader() string {
+	var builder strings.Builder
+	builder.WriteString(streamStyle.Inherit(textInverseAndBold).Render("STREAM"))
+	builder.WriteString(idStyle.Inherit(textInverseAndBold).Render("ID"))
+	builder.WriteString(consumerStyle.Inherit(textInverseAndBold).Render("CONSUMER"))
+	builder.WriteString(idleStyle.Inherit(textInverseAndBold).Render("IDLE"))
+	builder.WriteString(deliverStyle.Inherit(textInverseAndBold).Render("DELIV"))
+	return builder.String()
+}
+
+func entryRow(e *msgs.StuckEntry) string {
+	var builder strings.Builder
+	builder.WriteString(streamStyle.Render(e.Stream))
+	builder.WriteString(idStyle.Render(e.ID))
+	builder.WriteString(consumerStyle.Render(e.Consumer))
+	builder.WriteString(idleStyle.Render(e.Idle.Round(time.Second).String()))
+	builder.WriteString(deliverStyle.Render(fmt.Sprintf("%d", e.RetryCount)))
+	return builder.String()
+}
+
+// Run command after a delay, unit is seconds.
+func delayRunCommand(sec time.Duration, cmd tea.Cmd) tea.Cmd {
+	return func() tea.Msg {
+		time.Sleep(sec * time.Second)
+		return cmd()
+	}
+}
+
+// Command scan pending entries of every read group on every stream.
+func scanStuck(rdb *redis.Client, cfg Config) tea.Cmd {
+	return func() tea.Msg {
+		ctx := context.Background()
+
+		streams, err := rdb.Keys(ctx, streamPattern).Result()
+		if err != nil {
+			return msgs.StuckUpdateMsg{Err: err}
+		}
+
+		result := make([]msgs.StuckEntry, 0)
+		for _, stream := range streams {
+			groups, err := rdb.XInfoGroups(ctx, stream).Result()
+			// Key matches the pattern but is not a stream, it has no read group.
+			if err != nil && strings.HasPrefix(err.Error(), "WRONGTYPE") {
+				continue
+			}
+			if err != nil {
+				return msgs.StuckUpdateMsg{Err: err}
+			}
+
+			for _, group := range groups {
+				if group.Pending == 0 {
+					continue
+				}
+				// Page through every pending entry, start after last entry of page before.
+				start := "-"
+				for {
+					page, err := rdb.XPendingExt(ctx, &redis.XPendingExtArgs{
+						Stream: stream,
+						Group:  group.Name,
+						Start:  start,
+						End:    "+",
+						Count:  pendingPageSize,
+					}).Result()
+					if err != nil {
+						return msgs.StuckUpdateMsg{Err: err}
+					}
+					if len(page) == 0 {
+						break
+					}
+					for _, p := range page {
+						if p.Idle < cfg.Idle && p.RetryCount <= cfg.MaxDeliveries {
+							continue
+						}
+						result = append(result, msgs.StuckEntry{
+							Stream:     stream,
+							Group:      group.Name,
+							ID:         p.ID,
+							Consumer:   p.Consumer,
+							Idle:       p.Idle,
+							RetryCount: p.RetryCount,
+						})
+					}
+					start = "(" + page[len(page)-1].ID
+				}
+			}
+		}
+
+		// Most idle first.
+		sort.SliceStable(result, func(i, j int) bool {
+			return result[i].Idle > result[j].Idle
+		})
+		return msgs.StuckUpdateMsg{Entries: result}
+	}
+}
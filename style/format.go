@@ -0,0 +1,17 @@
+package style
+
+import "fmt"
+
+// Byte size like "512B" or "1.5M", units are 1024 based.
+func Bytes(n int64) string {
+	const unit = 1024
+	switch {
+	case n >= unit*unit*unit:
+		return fmt.Sprintf("%.1fG", float64(n)/(unit*unit*unit))
+	case n >= unit*unit:
+		return fmt.Sprintf("%.1fM", float64(n)/(unit*unit))
+	case n >= unit:
+		return fmt.Sprintf("%.1fK", float64(n)/unit)
+	}
+	return fmt.Sprintf("%dB", n)
+}
@@ -0,0 +1,114 @@
+package tab
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/charmbracelet/bubbles/help"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Component shown in a tab. Besides tea.Model it may implement StatusBar,
+// Helper and InputCapturer, the app checks them on the focused component.
+type Component interface {
+	tea.Model
+}
+
+// Component which can update status bar message.
+type StatusBar interface {
+	StatusBarView() string
+}
+
+// Component which tells what keys it takes in current state.
+type Helper interface {
+	Help() help.KeyMap
+}
+
+// Component which is editing text and needs every key press.
+type InputCapturer interface {
+	CapturingInput() bool
+}
+
+// Component which offers actions in command palette.
+type Actioner interface {
+	Actions() []Action
+}
+
+// Action runs by delivering Msg to the component offering it.
+type Action struct {
+	Title string
+	Msg   tea.Msg
+}
+
+// Tab description, what registry builds tabs from.
+type Tab struct {
+	// Unique name, shown in header and used in config.
+	Name string
+
+	// Key jumping to the tab, given by position when empty.
+	Key string
+
+	New func() Component
+}
+
+// Keys given to tabs without one, by position.
+var positionKeys = []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "0"}
+
+// Registry keeps tabs in registration order.
+type Registry struct {
+	tabs []Tab
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) Register(t Tab) {
+	if slices.ContainsFunc(r.tabs, func(e Tab) bool { return e.Name == t.Name }) {
+		panic(fmt.Sprintf("tab %q registered twice", t.Name))
+	}
+	r.tabs = append(r.tabs, t)
+}
+
+func (r *Registry) find(name string) (Tab, bool) {
+	for _, t := range r.tabs {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Tab{}, false
+}
+
+// Tabs to show. Order lists enabled tabs, all registered tabs in
+// registration order when empty, then disabled ones are removed.
+func (r *Registry) Build(order []string, disabled []string) ([]Tab, error) {
+	result := make([]Tab, 0, len(r.tabs))
+	if len(order) == 0 {
+		result = append(result, r.tabs...)
+	}
+	for _, name := range order {
+		t, ok := r.find(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown tab %q", name)
+		}
+		if !slices.ContainsFunc(result, func(e Tab) bool { return e.Name == name }) {
+			result = append(result, t)
+		}
+	}
+	for _, name := range disabled {
+		if _, ok := r.find(name); !ok {
+			return nil, fmt.Errorf("unknown tab %q", name)
+		}
+	}
+	result = slices.DeleteFunc(result, func(t Tab) bool { return slices.Contains(disabled, t.Name) })
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no tab enabled")
+	}
+
+	for i := range result {
+		if result[i].Key == "" && i < len(positionKeys) {
+			result[i].Key = positionKeys[i]
+		}
+	}
+	return result, nil
+}
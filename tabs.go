@@ -0,0 +1,41 @@
+package main
+
+import (
+	"gw/dispatcher/debugger/audit"
+	"gw/dispatcher/debugger/config"
+	"gw/dispatcher/debugger/console"
+	"gw/dispatcher/debugger/keylist"
+	"gw/dispatcher/debugger/keystats"
+	"gw/dispatcher/debugger/monitor"
+	"gw/dispatcher/debugger/pubsub"
+	"gw/dispatcher/debugger/queue"
+	"gw/dispatcher/debugger/runnerwatcher"
+	"gw/dispatcher/debugger/server"
+	"gw/dispatcher/debugger/stuck"
+	"gw/dispatcher/debugger/tab"
+)
+
+// What builtin tabs are built from.
+type tabDeps struct {
+	config       config.Config
+	stuck        stuck.Config
+	auditLog     *audit.Logger
+	keySeparator string
+	historyPath  string
+}
+
+// Register builtin tabs, registration order is the default tab order.
+func builtinTabs(deps tabDeps) *tab.Registry {
+	r := tab.NewRegistry()
+	r.Register(tab.Tab{Name: "runner", New: func() tab.Component { return runnerwatcher.New(deps.config.RunnerAddrs) }})
+	r.Register(tab.Tab{Name: "raw keys", New: func() tab.Component { return keylist.New(deps.keySeparator) }})
+	r.Register(tab.Tab{Name: "queue", New: func() tab.Component { return queue.New() }})
+	r.Register(tab.Tab{Name: "stuck", New: func() tab.Component { return stuck.New(deps.stuck) }})
+	r.Register(tab.Tab{Name: "audit", New: func() tab.Component { return audit.New(deps.auditLog) }})
+	r.Register(tab.Tab{Name: "keyspace", New: func() tab.Component { return keystats.New(deps.keySeparator) }})
+	r.Register(tab.Tab{Name: "server", New: func() tab.Component { return server.New() }})
+	r.Register(tab.Tab{Name: "monitor", New: func() tab.Component { return monitor.New() }})
+	r.Register(tab.Tab{Name: "pubsub", New: func() tab.Component { return pubsub.New() }})
+	r.Register(tab.Tab{Name: "console", New: func() tab.Component { return console.New(deps.historyPath) }})
+	return r
+}
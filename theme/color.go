@@ -0,0 +1,86 @@
+package theme
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
+
+// Color modes, auto detects terminal and respects NO_COLOR.
+var colorModes = map[string]termenv.Profile{
+	"truecolor": termenv.TrueColor,
+	"256":       termenv.ANSI256,
+	"16":        termenv.ANSI,
+	"none":      termenv.Ascii,
+}
+
+// Monochrome mode, state is told by attributes and symbols instead of colors.
+var mono bool
+
+// Set color mode, "auto" keeps what lipgloss detects. Colors of theme are
+// reduced to the nearest ones of the mode, mode "none" or NO_COLOR turns on
+// monochrome mode.
+func SetColorMode(mode string) error {
+	if mode != "auto" {
+		p, ok := colorModes[mode]
+		if !ok {
+			return fmt.Errorf("color mode %q not found, use auto, truecolor, 256, 16 or none", mode)
+		}
+		lipgloss.SetColorProfile(p)
+	}
+	mono = lipgloss.ColorProfile() == termenv.Ascii
+	for _, f := range listeners {
+		f(currentTheme)
+	}
+	return nil
+}
+
+func Mono() bool {
+	return mono
+}
+
+// Text on background color of theme. In monochrome mode attributes tell the
+// color instead, error is bold reverse, warning bold, success plain, others reverse.
+func (t Theme) On(bg lipgloss.Color) lipgloss.Style {
+	if !mono {
+		return lipgloss.NewStyle().Background(bg).Foreground(t.TextDark)
+	}
+	switch bg {
+	case t.Error:
+		return lipgloss.NewStyle().Reverse(true).Bold(true)
+	case t.Warning:
+		return lipgloss.NewStyle().Bold(true)
+	case t.Success:
+		return lipgloss.NewStyle()
+	default:
+		return lipgloss.NewStyle().Reverse(true)
+	}
+}
+
+// Text in color of theme, bold in monochrome mode unless it's success.
+func (t Theme) Fg(fg lipgloss.Color) lipgloss.Style {
+	if !mono {
+		return lipgloss.NewStyle().Foreground(fg)
+	}
+	if fg == t.Success {
+		return lipgloss.NewStyle()
+	}
+	return lipgloss.NewStyle().Bold(true)
+}
+
+// Symbol telling good or bad state, so state isn't told by color only.
+func Symbol(ok bool) string {
+	if ok {
+		return "✔"
+	}
+	return "✖"
+}
+
+// Symbol telling busy or idle, like Symbol it doesn't rely on color.
+func BusySymbol(busy bool) string {
+	if busy {
+		return "●"
+	}
+	return "○"
+}
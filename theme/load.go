@@ -0,0 +1,63 @@
+package theme
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Load every "<name>.yaml" in dir as theme of that name, a missing dir loads nothing.
+// Theme file of a built-in name replaces the built-in one.
+func Load(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		t, err := loadFile(path)
+		if err != nil {
+			return err
+		}
+		themes[strings.TrimSuffix(e.Name(), ext)] = t
+	}
+	return nil
+}
+
+// Colors not given in file come from base theme, dark when not given.
+func loadFile(path string) (Theme, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Theme{}, err
+	}
+	var head struct {
+		Base string `yaml:"base"`
+	}
+	if err := yaml.Unmarshal(data, &head); err != nil {
+		return Theme{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if head.Base == "" {
+		head.Base = Default
+	}
+	t, ok := themes[head.Base]
+	if !ok {
+		return Theme{}, fmt.Errorf("%s: base theme %q not found", path, head.Base)
+	}
+
+	if err := yaml.Unmarshal(data, &t); err != nil {
+		return Theme{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return t, nil
+}
@@ -1,25 +1,88 @@
 package theme
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"fmt"
+	"slices"
+
+	"github.com/charmbracelet/lipgloss"
+)
 
 type Theme struct {
-	TextLight         lipgloss.Color
-	TextDark          lipgloss.Color
-	Success           lipgloss.Color
-	Warning           lipgloss.Color
-	Error             lipgloss.Color
-	PanelDark         lipgloss.Color
-	PanelLight        lipgloss.Color
-	Background        lipgloss.Color
-	BackgroundInverse lipgloss.Color
+	TextLight         lipgloss.Color `yaml:"text_light"`
+	TextDark          lipgloss.Color `yaml:"text_dark"`
+	Success           lipgloss.Color `yaml:"success"`
+	Warning           lipgloss.Color `yaml:"warning"`
+	Error             lipgloss.Color `yaml:"error"`
+	PanelDark         lipgloss.Color `yaml:"panel_dark"`
+	PanelLight        lipgloss.Color `yaml:"panel_light"`
+	Background        lipgloss.Color `yaml:"background"`
+	BackgroundInverse lipgloss.Color `yaml:"background_inverse"`
 }
 
-var currentTheme = dark
+// Name of theme used when none is picked.
+const Default = "dark"
+
+var (
+	currentTheme = dark
+	currentName  = Default
+
+	// Themes by name, built-in ones and those loaded from files.
+	themes = map[string]Theme{
+		"dark":          dark,
+		"light":         light,
+		"high-contrast": highContrast,
+	}
+
+	// Functions rebuilding package styles, called on theme change.
+	listeners []func(Theme)
+)
 
 func G() Theme {
 	return currentTheme
 }
 
+// Name of current theme.
+func Name() string {
+	return currentName
+}
+
+// Names of every theme, sorted.
+func Names() []string {
+	result := make([]string, 0, len(themes))
+	for name := range themes {
+		result = append(result, name)
+	}
+	slices.Sort(result)
+	return result
+}
+
+// Switch to theme and rebuild styles of every package.
+func Set(name string) error {
+	t, ok := themes[name]
+	if !ok {
+		return fmt.Errorf("theme %q not found", name)
+	}
+	currentTheme, currentName = t, name
+	for _, f := range listeners {
+		f(t)
+	}
+	return nil
+}
+
+// Name of theme after current one, use to cycle themes.
+func Next() string {
+	names := Names()
+	i := slices.Index(names, currentName)
+	return names[(i+1)%len(names)]
+}
+
+// Build styles from current theme now and again on every theme change.
+// Packages call it in init for their package level styles.
+func OnChange(f func(Theme)) {
+	listeners = append(listeners, f)
+	f(currentTheme)
+}
+
 var dark = Theme{
 	TextLight:         lipgloss.Color("#FFFFFF"),
 	TextDark:          lipgloss.Color("#000000"),
@@ -31,3 +94,28 @@ var dark = Theme{
 	Background:        lipgloss.Color("#000000"),
 	BackgroundInverse: lipgloss.Color("#FFFFFF"),
 }
+
+// For terminals with light background, text on colored background is white.
+var light = Theme{
+	TextLight:         lipgloss.Color("#000000"),
+	TextDark:          lipgloss.Color("#FFFFFF"),
+	Success:           lipgloss.Color("#2b9348"),
+	Warning:           lipgloss.Color("#e85d04"),
+	Error:             lipgloss.Color("#d00000"),
+	PanelDark:         lipgloss.Color("#dde5ed"),
+	PanelLight:        lipgloss.Color("#0077b6"),
+	Background:        lipgloss.Color("#FFFFFF"),
+	BackgroundInverse: lipgloss.Color("#333333"),
+}
+
+var highContrast = Theme{
+	TextLight:         lipgloss.Color("#FFFFFF"),
+	TextDark:          lipgloss.Color("#000000"),
+	Success:           lipgloss.Color("#00FF00"),
+	Warning:           lipgloss.Color("#FFFF00"),
+	Error:             lipgloss.Color("#FF0000"),
+	PanelDark:         lipgloss.Color("#000000"),
+	PanelLight:        lipgloss.Color("#00FFFF"),
+	Background:        lipgloss.Color("#000000"),
+	BackgroundInverse: lipgloss.Color("#FFFFFF"),
+}